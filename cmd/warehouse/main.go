@@ -21,7 +21,7 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	a, err := app.NewApp(db, db)
+	a, err := app.NewApp(db, db, cfg)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"net/http"
+	"warehouse/internal/core/domain"
+)
+
+const actorHeader = "X-Actor"
+
+// ActorMiddleware кладет в контекст запроса автора операции из заголовка X-Actor
+func ActorMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if actor := r.Header.Get(actorHeader); actor != "" {
+			r = r.WithContext(domain.ContextWithActor(r.Context(), actor))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"warehouse/internal/core/domain"
+	"warehouse/internal/core/services"
+)
+
+func (h *GoodHandler) AdjustGood(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	q := r.URL.Query()
+	req := domain.AdjustmentRequest{}
+	var err error
+
+	if req.GoodID, err = queryInt(q, "goodID"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.WarehouseID, err = queryInt(q, "warehouseID"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Delta, err = queryInt(q, "delta"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Reason = q.Get("reason"); req.Reason == "" {
+		ErrorHandler(w, http.StatusBadRequest, errors.New(fmt.Sprintf(errQueryIsEmpty, "reason")))
+		return
+	}
+
+	if req.BinID, err = queryOptionalInt(q, "binID"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// серийные номера передаются повторяющимся параметром serial
+	req.Serials = q["serial"]
+
+	level, err := h.svc.AdjustStock(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, services.ErrGoodIDisNegative) ||
+			errors.Is(err, services.ErrWarehouseIDisNegative) ||
+			errors.Is(err, services.ErrDeltaIsZero) ||
+			errors.Is(err, services.ErrUnknownReason) ||
+			errors.Is(err, services.ErrGoodWarehouseIsNotExist) ||
+			errors.Is(err, services.ErrNotEnoughGoods) ||
+			errors.Is(err, services.ErrSerialIsEmpty) ||
+			errors.Is(err, services.ErrSerialIsDuplicated) ||
+			errors.Is(err, services.ErrSerialCountMismatch) ||
+			errors.Is(err, services.ErrSerialsIsRequired) ||
+			errors.Is(err, services.ErrGoodIsNotSerialTracked) ||
+			errors.Is(err, services.ErrSerialIsNotAvailable) ||
+			errors.Is(err, services.ErrBinIDisNegative) ||
+			errors.Is(err, services.ErrBinNotFound) ||
+			errors.Is(err, services.ErrBinIsNotInWarehouse) ||
+			errors.Is(err, services.ErrNotEnoughInBin) ||
+			errors.Is(err, services.ErrBelowReserved) {
+			ErrorHandler(w, http.StatusBadRequest, err)
+			return
+		}
+		if errors.Is(err, services.ErrSerialIsExist) ||
+			errors.Is(err, services.ErrGoodIsKit) {
+			ErrorHandler(w, http.StatusConflict, err)
+			return
+		}
+		ErrorHandler(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SuccessHandler(w, level)
+}
+
+func (h *GoodHandler) GetAdjustmentReasons(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	SuccessHandler(w, h.svc.AdjustmentReasons())
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"warehouse/internal/core/domain"
+	"warehouse/internal/core/services"
+)
+
+func alertErrorStatus(err error) int {
+	if errors.Is(err, services.ErrGoodIDisNegative) ||
+		errors.Is(err, services.ErrWarehouseIDisNegative) ||
+		errors.Is(err, services.ErrInvalidThreshold) ||
+		errors.Is(err, services.ErrGoodWarehouseIsNotExist) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *GoodHandler) SetStockThreshold(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	q := r.URL.Query()
+	t := domain.StockThreshold{}
+	var err error
+
+	if t.GoodID, err = queryInt(q, "goodID"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if t.WarehouseID, err = queryInt(q, "warehouseID"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if t.ReorderPoint, err = queryInt(q, "reorderPoint"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if t.TargetLevel, err = queryInt(q, "targetLevel"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = h.svc.SetStockThreshold(r.Context(), t); err != nil {
+		ErrorHandler(w, alertErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, t)
+}
+
+func (h *GoodHandler) GetStockAlerts(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	q := r.URL.Query()
+	filter := domain.StockAlertFilter{}
+	var err error
+
+	if filter.GoodID, err = queryOptionalInt(q, "goodID"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if filter.WarehouseID, err = queryOptionalInt(q, "warehouseID"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if sOpen := q.Get("open"); sOpen != "" {
+		if filter.OpenOnly, err = strconv.ParseBool(sOpen); err != nil {
+			ErrorHandler(w, http.StatusBadRequest, errors.New(fmt.Sprintf(errQueryIsNotBool, "open")))
+			return
+		}
+	}
+
+	alerts, err := h.svc.GetStockAlerts(r.Context(), filter)
+	if err != nil {
+		ErrorHandler(w, alertErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, alerts)
+}
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"warehouse/internal/core/domain"
+	"warehouse/internal/core/services"
+)
+
+func binErrorStatus(err error) int {
+	if errors.Is(err, services.ErrWarehouseIDisNegative) ||
+		errors.Is(err, services.ErrZoneIDisNegative) ||
+		errors.Is(err, services.ErrAisleIDisNegative) ||
+		errors.Is(err, services.ErrBinIDisNegative) ||
+		errors.Is(err, services.ErrGoodIDisNegative) ||
+		errors.Is(err, services.ErrQuantityIsNegative) ||
+		errors.Is(err, services.ErrLocationCodeIsEmpty) ||
+		errors.Is(err, services.ErrSameBin) ||
+		errors.Is(err, services.ErrWarehouseNotFound) ||
+		errors.Is(err, services.ErrZoneNotFound) ||
+		errors.Is(err, services.ErrAisleNotFound) ||
+		errors.Is(err, services.ErrBinNotFound) ||
+		errors.Is(err, services.ErrGoodWarehouseIsNotExist) ||
+		errors.Is(err, services.ErrBinsInDifferentWarehouses) ||
+		errors.Is(err, services.ErrNotEnoughUnbinned) ||
+		errors.Is(err, services.ErrNotEnoughInBin) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, services.ErrLocationIsExist) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *GoodHandler) CreateZone(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	q := r.URL.Query()
+	warehouseID, err := queryInt(q, "warehouseID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	z, err := h.svc.CreateZone(r.Context(), warehouseID, q.Get("code"))
+	if err != nil {
+		ErrorHandler(w, binErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, z)
+}
+
+func (h *GoodHandler) CreateAisle(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	q := r.URL.Query()
+	zoneID, err := queryInt(q, "zoneID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	a, err := h.svc.CreateAisle(r.Context(), zoneID, q.Get("code"))
+	if err != nil {
+		ErrorHandler(w, binErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, a)
+}
+
+func (h *GoodHandler) CreateBin(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	q := r.URL.Query()
+	aisleID, err := queryInt(q, "aisleID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	b, err := h.svc.CreateBin(r.Context(), aisleID, q.Get("code"))
+	if err != nil {
+		ErrorHandler(w, binErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, b)
+}
+
+func (h *GoodHandler) GetWarehouseLayout(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	warehouseID, err := queryInt(r.URL.Query(), "warehouseID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	layout, err := h.svc.GetWarehouseLayout(r.Context(), warehouseID)
+	if err != nil {
+		ErrorHandler(w, binErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, layout)
+}
+
+func (h *GoodHandler) GetBin(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	id, err := queryInt(r.URL.Query(), "binID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	b, err := h.svc.GetBin(r.Context(), id)
+	if err != nil {
+		ErrorHandler(w, binErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, b)
+}
+
+func (h *GoodHandler) PutAway(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	q := r.URL.Query()
+	binID, err := queryInt(q, "binID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	goodID, err := queryInt(q, "goodID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	quantity, err := queryInt(q, "quantity")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	b, err := h.svc.PutAway(r.Context(), binID, goodID, quantity)
+	if err != nil {
+		ErrorHandler(w, binErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, b)
+}
+
+func (h *GoodHandler) MoveBinStock(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	q := r.URL.Query()
+	req := domain.BinMoveRequest{}
+	var err error
+
+	if req.FromBinID, err = queryInt(q, "fromBinID"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.ToBinID, err = queryInt(q, "toBinID"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.GoodID, err = queryInt(q, "goodID"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Quantity, err = queryInt(q, "quantity"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	bins, err := h.svc.MoveBinStock(r.Context(), req)
+	if err != nil {
+		ErrorHandler(w, binErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, bins)
+}
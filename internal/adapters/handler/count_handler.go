@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"warehouse/internal/core/domain"
+	"warehouse/internal/core/services"
+)
+
+func countSessionErrorStatus(err error) int {
+	if errors.Is(err, services.ErrWarehouseIDisNegative) ||
+		errors.Is(err, services.ErrWarehouseIsNotExist) ||
+		errors.Is(err, services.ErrGoodIDisNegative) ||
+		errors.Is(err, services.ErrGoodIsNotExist) ||
+		errors.Is(err, services.ErrCountIsNegative) ||
+		errors.Is(err, services.ErrCountedGoodsIsEmpty) ||
+		errors.Is(err, services.ErrCountSessionIDisNegative) ||
+		errors.Is(err, services.ErrCountSessionNotFound) ||
+		errors.Is(err, services.ErrSerialIsEmpty) ||
+		errors.Is(err, services.ErrSerialIsDuplicated) ||
+		errors.Is(err, services.ErrSerialCountMismatch) ||
+		errors.Is(err, services.ErrSerialsIsRequired) ||
+		errors.Is(err, services.ErrGoodIsNotSerialTracked) ||
+		errors.Is(err, services.ErrBinIDisNegative) ||
+		errors.Is(err, services.ErrBinNotFound) ||
+		errors.Is(err, services.ErrBinIsNotInWarehouse) ||
+		errors.Is(err, services.ErrNotEnoughInBin) ||
+		errors.Is(err, services.ErrSerialIsNotAvailable) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, services.ErrCountSessionIsExist) ||
+		errors.Is(err, services.ErrCountSessionIsNotOpen) ||
+		errors.Is(err, services.ErrBelowReserved) ||
+		errors.Is(err, services.ErrNotEnoughGoods) ||
+		errors.Is(err, services.ErrSerialIsExist) ||
+		errors.Is(err, services.ErrGoodIsKit) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *GoodHandler) OpenCountSession(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	warehouseID, err := queryInt(r.URL.Query(), "warehouseID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s, err := h.svc.OpenCountSession(r.Context(), warehouseID)
+	if err != nil {
+		ErrorHandler(w, countSessionErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, s)
+}
+
+func (h *GoodHandler) GetCountSession(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	id, err := queryInt(r.URL.Query(), "sessionID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s, err := h.svc.GetCountSession(r.Context(), id)
+	if err != nil {
+		ErrorHandler(w, countSessionErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, s)
+}
+
+func (h *GoodHandler) SubmitCount(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	id, err := queryInt(r.URL.Query(), "sessionID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	counted := make([]domain.CountedGood, 0)
+	if err = json.NewDecoder(r.Body).Decode(&counted); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, fmt.Errorf("error decode request body: %w", err))
+		return
+	}
+
+	s, err := h.svc.SubmitCount(r.Context(), id, counted)
+	if err != nil {
+		ErrorHandler(w, countSessionErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, s)
+}
+
+func (h *GoodHandler) ApproveCountSession(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	id, err := queryInt(r.URL.Query(), "sessionID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s, err := h.svc.ApproveCountSession(r.Context(), id)
+	if err != nil {
+		ErrorHandler(w, countSessionErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, s)
+}
+
+func (h *GoodHandler) CancelCountSession(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	id, err := queryInt(r.URL.Query(), "sessionID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	s, err := h.svc.CancelCountSession(r.Context(), id)
+	if err != nil {
+		ErrorHandler(w, countSessionErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, s)
+}
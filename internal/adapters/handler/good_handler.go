@@ -4,15 +4,21 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 	"warehouse/internal/core/domain"
 	"warehouse/internal/core/services"
 )
 
 var ( //errors
-	errQueryIsEmpty     = "query \"%s\" is empty"
-	errQueryIsNotNumber = "query \"%s\" is not a number"
+	errQueryIsEmpty       = "query \"%s\" is empty"
+	errQueryIsNotNumber   = "query \"%s\" is not a number"
+	errQueryIsNotDuration = "query \"%s\" is not a duration"
+	errQueryIsNotBool     = "query \"%s\" is not a boolean"
+	errQueryIsNotTime     = "query \"%s\" is not a RFC3339 time"
 )
 
 type GoodHandler struct {
@@ -109,14 +115,97 @@ func (h *GoodHandler) DeleteGood(w http.ResponseWriter, r *http.Request) {
 func (h *GoodHandler) ReserveGood(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
-	pairs := make([]domain.PairGoodWarehouse, 0)
-	if err := json.NewDecoder(r.Body).Decode(&pairs); err != nil {
+	orderRef, ttl, err := reservationQuery(r.URL.Query())
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	req := domain.ReservationRequest{
+		OrderRef: orderRef,
+		TTL:      ttl,
+		Pairs:    make([]domain.PairGoodWarehouse, 0),
+	}
+
+	if sAtomic := r.URL.Query().Get("atomic"); sAtomic != "" {
+		atomic, err := strconv.ParseBool(sAtomic)
+		if err != nil {
+			ErrorHandler(w, http.StatusBadRequest, errors.New(fmt.Sprintf(errQueryIsNotBool, "atomic")))
+			return
+		}
+		req.Atomic = atomic
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req.Pairs); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, fmt.Errorf("error decode request body: %w", err))
+		return
+	}
+
+	res, err := h.svc.Reserve(r.Context(), r.Header.Get(idempotencyKeyHeader), req)
+	if err != nil {
+		if status, ok := idempotencyErrorStatus(err); ok {
+			ErrorHandler(w, status, err)
+			return
+		}
+		if errors.Is(err, services.ErrTTLIsNegative) {
+			ErrorHandler(w, http.StatusBadRequest, err)
+			return
+		}
+		ErrorHandler(w, http.StatusInternalServerError, fmt.Errorf("error reserve: %w", err))
+		return
+	}
+
+	SuccessHandler(w, res)
+}
+
+// reservationQuery разбирает общие для резерваций необязательные параметры orderRef и ttl
+func reservationQuery(q url.Values) (string, time.Duration, error) {
+	orderRef := q.Get("orderRef")
+
+	sTTL := q.Get("ttl")
+	if sTTL == "" {
+		return orderRef, 0, nil
+	}
+
+	ttl, err := time.ParseDuration(sTTL)
+	if err != nil {
+		return "", 0, errors.New(fmt.Sprintf(errQueryIsNotDuration, "ttl"))
+	}
+	return orderRef, ttl, nil
+}
+
+func (h *GoodHandler) ReserveGoodAuto(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	orderRef, ttl, err := reservationQuery(r.URL.Query())
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	req := domain.AutoReservationRequest{
+		OrderRef: orderRef,
+		TTL:      ttl,
+		Strategy: r.URL.Query().Get("strategy"),
+		Lines:    make([]domain.GoodQuantity, 0),
+	}
+
+	if err = json.NewDecoder(r.Body).Decode(&req.Lines); err != nil {
 		ErrorHandler(w, http.StatusBadRequest, fmt.Errorf("error decode request body: %w", err))
 		return
 	}
 
-	res, err := h.svc.Reserve(r.Context(), pairs)
+	res, err := h.svc.ReserveAuto(r.Context(), r.Header.Get(idempotencyKeyHeader), req)
 	if err != nil {
+		if status, ok := idempotencyErrorStatus(err); ok {
+			ErrorHandler(w, status, err)
+			return
+		}
+		if errors.Is(err, services.ErrTTLIsNegative) ||
+			errors.Is(err, services.ErrUnknownStrategy) {
+			ErrorHandler(w, http.StatusBadRequest, err)
+			return
+		}
 		ErrorHandler(w, http.StatusInternalServerError, fmt.Errorf("error reserve: %w", err))
 		return
 	}
@@ -124,17 +213,48 @@ func (h *GoodHandler) ReserveGood(w http.ResponseWriter, r *http.Request) {
 	SuccessHandler(w, res)
 }
 
+func (h *GoodHandler) GetReservation(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	id, err := queryInt(r.URL.Query(), "reservationID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res, err := h.svc.GetReservation(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, services.ErrReservationIDisNegative) || errors.Is(err, services.ErrReservationNotFound) {
+			ErrorHandler(w, http.StatusBadRequest, err)
+			return
+		}
+		ErrorHandler(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SuccessHandler(w, res)
+}
+
 func (h *GoodHandler) ReleaseReservationGood(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
+	if r.URL.Query().Has("reservationID") {
+		h.releaseReservationByID(w, r)
+		return
+	}
+
 	pairs := make([]domain.PairGoodWarehouse, 0)
 	if err := json.NewDecoder(r.Body).Decode(&pairs); err != nil {
 		ErrorHandler(w, http.StatusBadRequest, fmt.Errorf("error decode request body: %w", err))
 		return
 	}
 
-	res, err := h.svc.ReleaseReservation(r.Context(), pairs)
+	res, err := h.svc.ReleaseReservation(r.Context(), r.Header.Get(idempotencyKeyHeader), pairs)
 	if err != nil {
+		if status, ok := idempotencyErrorStatus(err); ok {
+			ErrorHandler(w, status, err)
+			return
+		}
 		ErrorHandler(w, http.StatusInternalServerError, fmt.Errorf("error reserve: %w", err))
 		return
 	}
@@ -142,6 +262,146 @@ func (h *GoodHandler) ReleaseReservationGood(w http.ResponseWriter, r *http.Requ
 	SuccessHandler(w, res)
 }
 
+func (h *GoodHandler) releaseReservationByID(w http.ResponseWriter, r *http.Request) {
+	id, err := queryInt(r.URL.Query(), "reservationID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res, err := h.svc.ReleaseReservationByID(r.Context(), r.Header.Get(idempotencyKeyHeader), id)
+	if err != nil {
+		if status, ok := idempotencyErrorStatus(err); ok {
+			ErrorHandler(w, status, err)
+			return
+		}
+		if errors.Is(err, services.ErrReservationIDisNegative) ||
+			errors.Is(err, services.ErrReservationNotFound) ||
+			errors.Is(err, services.ErrReservationIsNotActive) {
+			ErrorHandler(w, http.StatusBadRequest, err)
+			return
+		}
+		if errors.Is(err, services.ErrReservationHasOrder) {
+			ErrorHandler(w, http.StatusConflict, err)
+			return
+		}
+		ErrorHandler(w, http.StatusInternalServerError, fmt.Errorf("error release reservation: %w", err))
+		return
+	}
+
+	SuccessHandler(w, res)
+}
+
+func (h *GoodHandler) FulfilReservation(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	id, err := queryInt(r.URL.Query(), "reservationID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// ячейки, из которых взяты единицы, передаются необязательным телом запроса
+	picks := make([]domain.Pick, 0)
+	if err = json.NewDecoder(r.Body).Decode(&picks); err != nil && !errors.Is(err, io.EOF) {
+		ErrorHandler(w, http.StatusBadRequest, fmt.Errorf("error decode request body: %w", err))
+		return
+	}
+
+	sh, err := h.svc.FulfilReservation(r.Context(), id, picks)
+	if err != nil {
+		if errors.Is(err, services.ErrReservationIDisNegative) ||
+			errors.Is(err, services.ErrReservationNotFound) ||
+			errors.Is(err, services.ErrReservationIsNotActive) ||
+			errors.Is(err, services.ErrNotEnoughReserved) ||
+			errors.Is(err, services.ErrGoodIDisNegative) ||
+			errors.Is(err, services.ErrQuantityIsNegative) ||
+			errors.Is(err, services.ErrPickExceedsQuantity) ||
+			errors.Is(err, services.ErrBinIDisNegative) ||
+			errors.Is(err, services.ErrBinNotFound) ||
+			errors.Is(err, services.ErrBinIsNotInWarehouse) ||
+			errors.Is(err, services.ErrNotEnoughInBin) ||
+			errors.Is(err, services.ErrWarehouseIsUnavailable) {
+			ErrorHandler(w, http.StatusBadRequest, err)
+			return
+		}
+		if errors.Is(err, services.ErrReservationHasOrder) {
+			ErrorHandler(w, http.StatusConflict, err)
+			return
+		}
+		ErrorHandler(w, http.StatusInternalServerError, fmt.Errorf("error fulfil reservation: %w", err))
+		return
+	}
+
+	SuccessHandler(w, sh)
+}
+
+func (h *GoodHandler) GetShipment(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	id, err := queryInt(r.URL.Query(), "shipmentID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	sh, err := h.svc.GetShipment(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, services.ErrShipmentIDisNegative) || errors.Is(err, services.ErrShipmentNotFound) {
+			ErrorHandler(w, http.StatusBadRequest, err)
+			return
+		}
+		ErrorHandler(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SuccessHandler(w, sh)
+}
+
+func (h *GoodHandler) GetStockMovements(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	q := r.URL.Query()
+	filter := domain.StockMovementFilter{}
+	var err error
+
+	if filter.GoodID, err = queryOptionalInt(q, "goodID"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if filter.WarehouseID, err = queryOptionalInt(q, "warehouseID"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if filter.From, err = queryOptionalTime(q, "from"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if filter.To, err = queryOptionalTime(q, "to"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if filter.Limit, err = queryOptionalInt(q, "limit"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	movements, err := h.svc.GetStockMovements(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, services.ErrGoodIDisNegative) ||
+			errors.Is(err, services.ErrWarehouseIDisNegative) ||
+			errors.Is(err, services.ErrInvalidTimeRange) ||
+			errors.Is(err, services.ErrInvalidLimit) {
+			ErrorHandler(w, http.StatusBadRequest, err)
+			return
+		}
+		ErrorHandler(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SuccessHandler(w, movements)
+}
+
 func (h *GoodHandler) AddGoodOnWarehouse(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 
@@ -184,7 +444,44 @@ func (h *GoodHandler) AddGoodOnWarehouse(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := h.svc.AddGoodOnWarehouse(r.Context(), goodID, warehouseID, cnt); err != nil {
+	var lot *domain.LotRef
+	if number := q.Get("lot"); number != "" {
+		lot = &domain.LotRef{Number: number}
+		if lot.ExpiresAt, err = queryOptionalTime(q, "expiresAt"); err != nil {
+			ErrorHandler(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	// серийные номера передаются повторяющимся параметром serial
+	serials := q["serial"]
+
+	if err := h.svc.AddGoodOnWarehouse(r.Context(), r.Header.Get(idempotencyKeyHeader), goodID, warehouseID, cnt, lot, serials); err != nil {
+		if status, ok := idempotencyErrorStatus(err); ok {
+			ErrorHandler(w, status, err)
+			return
+		}
+		if errors.Is(err, services.ErrGoodIDisNegative) ||
+			errors.Is(err, services.ErrWarehouseIDisNegative) ||
+			errors.Is(err, services.ErrCountIsNegative) ||
+			errors.Is(err, services.ErrLotExpiryIsEmpty) ||
+			errors.Is(err, services.ErrLotExpiryMismatch) ||
+			errors.Is(err, services.ErrSerialIsEmpty) ||
+			errors.Is(err, services.ErrSerialIsDuplicated) ||
+			errors.Is(err, services.ErrSerialCountMismatch) ||
+			errors.Is(err, services.ErrSerialsIsRequired) ||
+			errors.Is(err, services.ErrGoodIsNotSerialTracked) ||
+			errors.Is(err, services.ErrGoodWarehouseIsNotExist) ||
+			errors.Is(err, services.ErrWarehouseIsUnavailable) {
+			ErrorHandler(w, http.StatusBadRequest, err)
+			return
+		}
+		if errors.Is(err, services.ErrSerialIsExist) ||
+			errors.Is(err, services.ErrCapacityExceeded) ||
+			errors.Is(err, services.ErrGoodIsKit) {
+			ErrorHandler(w, http.StatusConflict, err)
+			return
+		}
 		ErrorHandler(w, http.StatusInternalServerError, err)
 		return
 	}
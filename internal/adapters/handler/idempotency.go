@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"warehouse/internal/core/services"
+)
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// idempotencyErrorStatus подбирает статус для ошибок, связанных с Idempotency-Key
+func idempotencyErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, services.ErrIdempotencyKeyInProgress):
+		return http.StatusConflict, true
+	case errors.Is(err, services.ErrIdempotencyKeyMismatch):
+		return http.StatusUnprocessableEntity, true
+	}
+	return 0, false
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"warehouse/internal/core/domain"
+	"warehouse/internal/core/services"
+)
+
+func (h *GoodHandler) SetKitComponents(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	goodID, err := queryInt(r.URL.Query(), "goodID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	components := make([]domain.KitComponent, 0)
+	if err = json.NewDecoder(r.Body).Decode(&components); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, fmt.Errorf("error decode request body: %w", err))
+		return
+	}
+
+	g, err := h.svc.SetKitComponents(r.Context(), goodID, components)
+	if err != nil {
+		if errors.Is(err, services.ErrGoodIDisNegative) ||
+			errors.Is(err, services.ErrQuantityIsNegative) ||
+			errors.Is(err, services.ErrKitContainsItself) ||
+			errors.Is(err, services.ErrKitComponentsIsDuplicated) ||
+			errors.Is(err, services.ErrGoodNotFound) {
+			ErrorHandler(w, http.StatusBadRequest, err)
+			return
+		}
+		if errors.Is(err, services.ErrKitHasStock) ||
+			errors.Is(err, services.ErrNestedKit) {
+			ErrorHandler(w, http.StatusConflict, err)
+			return
+		}
+		ErrorHandler(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SuccessHandler(w, g)
+}
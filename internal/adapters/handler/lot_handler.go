@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"warehouse/internal/core/services"
+)
+
+func (h *GoodHandler) GetExpiringLots(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	q := r.URL.Query()
+	days, err := queryInt(q, "days")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	warehouseID, err := queryOptionalInt(q, "warehouseID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	lots, err := h.svc.GetExpiringLots(r.Context(), warehouseID, days)
+	if err != nil {
+		if errors.Is(err, services.ErrWarehouseIDisNegative) ||
+			errors.Is(err, services.ErrDaysIsNegative) {
+			ErrorHandler(w, http.StatusBadRequest, err)
+			return
+		}
+		ErrorHandler(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SuccessHandler(w, lots)
+}
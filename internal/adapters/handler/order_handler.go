@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"warehouse/internal/core/domain"
+	"warehouse/internal/core/services"
+)
+
+func orderErrorStatus(err error) int {
+	if errors.Is(err, services.ErrReservationIDisNegative) ||
+		errors.Is(err, services.ErrReservationNotFound) ||
+		errors.Is(err, services.ErrOrderIDisNegative) ||
+		errors.Is(err, services.ErrOrderNotFound) ||
+		errors.Is(err, services.ErrPickListIDisNegative) ||
+		errors.Is(err, services.ErrPickListNotFound) ||
+		errors.Is(err, services.ErrPickedGoodsIsEmpty) ||
+		errors.Is(err, services.ErrGoodIDisNegative) ||
+		errors.Is(err, services.ErrQuantityIsNegative) ||
+		errors.Is(err, services.ErrGoodIsNotInPickList) ||
+		errors.Is(err, services.ErrPickExceedsQuantity) ||
+		errors.Is(err, services.ErrBinIDisNegative) ||
+		errors.Is(err, services.ErrBinNotFound) ||
+		errors.Is(err, services.ErrBinIsNotInWarehouse) ||
+		errors.Is(err, services.ErrNotEnoughInBin) ||
+		errors.Is(err, services.ErrWarehouseIsUnavailable) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, services.ErrReservationIsNotActive) ||
+		errors.Is(err, services.ErrOrderIsExist) ||
+		errors.Is(err, services.ErrOrderIsNotPicking) ||
+		errors.Is(err, services.ErrNotEnoughReserved) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *GoodHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	reservationID, err := queryInt(r.URL.Query(), "reservationID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	o, err := h.svc.CreateOrder(r.Context(), reservationID)
+	if err != nil {
+		ErrorHandler(w, orderErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, o)
+}
+
+func (h *GoodHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	id, err := queryInt(r.URL.Query(), "orderID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	o, err := h.svc.GetOrder(r.Context(), id)
+	if err != nil {
+		ErrorHandler(w, orderErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, o)
+}
+
+func (h *GoodHandler) PickGoods(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	pickListID, err := queryInt(r.URL.Query(), "pickListID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	picked := make([]domain.Pick, 0)
+	if err = json.NewDecoder(r.Body).Decode(&picked); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, fmt.Errorf("error decode request body: %w", err))
+		return
+	}
+
+	o, err := h.svc.PickGoods(r.Context(), pickListID, picked)
+	if err != nil {
+		ErrorHandler(w, orderErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, o)
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"warehouse/internal/core/services"
+)
+
+func (h *GoodHandler) GetPacking(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	q := r.URL.Query()
+	reservationID, err := queryOptionalInt(q, "reservationID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	orderID, err := queryOptionalInt(q, "orderID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	p, err := h.svc.SuggestPacking(r.Context(), reservationID, orderID)
+	if err != nil {
+		if errors.Is(err, services.ErrPackingSourceIsInvalid) ||
+			errors.Is(err, services.ErrReservationIDisNegative) ||
+			errors.Is(err, services.ErrOrderIDisNegative) ||
+			errors.Is(err, services.ErrReservationNotFound) ||
+			errors.Is(err, services.ErrOrderNotFound) ||
+			errors.Is(err, services.ErrGoodNotFound) ||
+			errors.Is(err, services.ErrGoodDoesNotFitBox) {
+			ErrorHandler(w, http.StatusBadRequest, err)
+			return
+		}
+		if errors.Is(err, services.ErrReservationIsNotActive) {
+			ErrorHandler(w, http.StatusConflict, err)
+			return
+		}
+		ErrorHandler(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SuccessHandler(w, p)
+}
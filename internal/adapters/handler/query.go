@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// queryInt достает из query обязательный числовой параметр
+func queryInt(q url.Values, name string) (int, error) {
+	s := q.Get(name)
+	if s == "" {
+		return 0, errors.New(fmt.Sprintf(errQueryIsEmpty, name))
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, errors.New(fmt.Sprintf(errQueryIsNotNumber, name))
+	}
+
+	return v, nil
+}
+
+// queryOptionalInt достает необязательный числовой параметр, 0 - если его нет
+func queryOptionalInt(q url.Values, name string) (int, error) {
+	if q.Get(name) == "" {
+		return 0, nil
+	}
+	return queryInt(q, name)
+}
+
+// queryOptionalTime достает необязательный параметр в формате RFC3339
+func queryOptionalTime(q url.Values, name string) (time.Time, error) {
+	s := q.Get(name)
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, errors.New(fmt.Sprintf(errQueryIsNotTime, name))
+	}
+
+	return t, nil
+}
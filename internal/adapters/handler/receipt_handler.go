@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"warehouse/internal/core/domain"
+	"warehouse/internal/core/services"
+)
+
+func receiptErrorStatus(err error) int {
+	if errors.Is(err, services.ErrWarehouseIDisNegative) ||
+		errors.Is(err, services.ErrGoodIDisNegative) ||
+		errors.Is(err, services.ErrQuantityIsNegative) ||
+		errors.Is(err, services.ErrSupplierRefIsEmpty) ||
+		errors.Is(err, services.ErrReceiptLinesIsEmpty) ||
+		errors.Is(err, services.ErrReceiptIDisNegative) ||
+		errors.Is(err, services.ErrReceiptNotFound) ||
+		errors.Is(err, services.ErrLotNumberIsEmpty) ||
+		errors.Is(err, services.ErrLotExpiryIsEmpty) ||
+		errors.Is(err, services.ErrLotExpiryMismatch) ||
+		errors.Is(err, services.ErrSerialIsEmpty) ||
+		errors.Is(err, services.ErrSerialIsDuplicated) ||
+		errors.Is(err, services.ErrSerialCountMismatch) ||
+		errors.Is(err, services.ErrSerialsIsRequired) ||
+		errors.Is(err, services.ErrGoodIsNotSerialTracked) ||
+		errors.Is(err, services.ErrGoodWarehouseIsNotExist) ||
+		errors.Is(err, services.ErrWarehouseIsUnavailable) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, services.ErrReceiptIsClosed) ||
+		errors.Is(err, services.ErrSerialIsExist) ||
+		errors.Is(err, services.ErrCapacityExceeded) ||
+		errors.Is(err, services.ErrGoodIsKit) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *GoodHandler) CreateReceipt(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	q := r.URL.Query()
+	warehouseID, err := queryInt(q, "warehouseID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	lines := make([]domain.GoodQuantity, 0)
+	if err = json.NewDecoder(r.Body).Decode(&lines); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, fmt.Errorf("error decode request body: %w", err))
+		return
+	}
+
+	rc, err := h.svc.CreateReceipt(r.Context(), warehouseID, q.Get("supplierRef"), lines)
+	if err != nil {
+		ErrorHandler(w, receiptErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, rc)
+}
+
+func (h *GoodHandler) GetReceipt(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	id, err := queryInt(r.URL.Query(), "receiptID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rc, err := h.svc.GetReceipt(r.Context(), id)
+	if err != nil {
+		ErrorHandler(w, receiptErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, rc)
+}
+
+func (h *GoodHandler) ReceiveReceipt(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	id, err := queryInt(r.URL.Query(), "receiptID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	lines := make([]domain.GoodQuantity, 0)
+	if err = json.NewDecoder(r.Body).Decode(&lines); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, fmt.Errorf("error decode request body: %w", err))
+		return
+	}
+
+	rc, err := h.svc.ReceiveReceipt(r.Context(), id, lines)
+	if err != nil {
+		ErrorHandler(w, receiptErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, rc)
+}
+
+func (h *GoodHandler) CloseReceipt(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	id, err := queryInt(r.URL.Query(), "receiptID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rc, err := h.svc.CloseReceipt(r.Context(), id)
+	if err != nil {
+		ErrorHandler(w, receiptErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, rc)
+}
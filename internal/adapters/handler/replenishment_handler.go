@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"warehouse/internal/core/domain"
+	"warehouse/internal/core/services"
+)
+
+func (h *GoodHandler) GetReplenishment(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	q := r.URL.Query()
+	req := domain.ReplenishmentRequest{}
+	var err error
+
+	if req.GoodID, err = queryOptionalInt(q, "goodID"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.WarehouseID, err = queryOptionalInt(q, "warehouseID"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Params.WindowDays, err = queryOptionalInt(q, "windowDays"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Params.LeadTimeDays, err = queryOptionalInt(q, "leadTimeDays"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Params.SafetyStockDays, err = queryOptionalInt(q, "safetyStockDays"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	res, err := h.svc.GetReplenishment(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, services.ErrGoodIDisNegative) ||
+			errors.Is(err, services.ErrWarehouseIDisNegative) ||
+			errors.Is(err, services.ErrInvalidReplenishmentParams) {
+			ErrorHandler(w, http.StatusBadRequest, err)
+			return
+		}
+		ErrorHandler(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SuccessHandler(w, res)
+}
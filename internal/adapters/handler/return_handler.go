@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"warehouse/internal/core/domain"
+	"warehouse/internal/core/services"
+)
+
+func returnErrorStatus(err error) int {
+	if errors.Is(err, services.ErrReturnSourceIsInvalid) ||
+		errors.Is(err, services.ErrShipmentIDisNegative) ||
+		errors.Is(err, services.ErrShipmentNotFound) ||
+		errors.Is(err, services.ErrOrderIDisNegative) ||
+		errors.Is(err, services.ErrOrderNotFound) ||
+		errors.Is(err, services.ErrWarehouseIDisNegative) ||
+		errors.Is(err, services.ErrWarehouseIsNotExist) ||
+		errors.Is(err, services.ErrWarehouseIsUnavailable) ||
+		errors.Is(err, services.ErrReturnLinesIsEmpty) ||
+		errors.Is(err, services.ErrGoodIDisNegative) ||
+		errors.Is(err, services.ErrQuantityIsNegative) ||
+		errors.Is(err, services.ErrUnknownDisposition) ||
+		errors.Is(err, services.ErrReturnExceedsShipped) ||
+		errors.Is(err, services.ErrReturnIDisNegative) ||
+		errors.Is(err, services.ErrReturnNotFound) ||
+		errors.Is(err, services.ErrLotExpiryMismatch) ||
+		errors.Is(err, services.ErrSerialIsEmpty) ||
+		errors.Is(err, services.ErrSerialIsDuplicated) ||
+		errors.Is(err, services.ErrSerialCountMismatch) ||
+		errors.Is(err, services.ErrSerialsIsRequired) ||
+		errors.Is(err, services.ErrGoodIsNotSerialTracked) ||
+		errors.Is(err, services.ErrSerialIsNotAvailable) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, services.ErrOrderIsNotShipped) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *GoodHandler) CreateReturn(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	q := r.URL.Query()
+	req := domain.ReturnRequest{Reason: q.Get("reason")}
+	var err error
+
+	if req.ShipmentID, err = queryOptionalInt(q, "shipmentID"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.OrderID, err = queryOptionalInt(q, "orderID"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.WarehouseID, err = queryInt(q, "warehouseID"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	req.Lines = make([]domain.ReturnLine, 0)
+	if err = json.NewDecoder(r.Body).Decode(&req.Lines); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, fmt.Errorf("error decode request body: %w", err))
+		return
+	}
+
+	rt, err := h.svc.CreateReturn(r.Context(), req)
+	if err != nil {
+		ErrorHandler(w, returnErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, rt)
+}
+
+func (h *GoodHandler) GetReturn(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	id, err := queryInt(r.URL.Query(), "returnID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rt, err := h.svc.GetReturn(r.Context(), id)
+	if err != nil {
+		ErrorHandler(w, returnErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, rt)
+}
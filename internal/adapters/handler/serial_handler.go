@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"warehouse/internal/core/services"
+)
+
+func (h *GoodHandler) GetSerial(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	s, err := h.svc.GetSerial(r.Context(), r.URL.Query().Get("serial"))
+	if err != nil {
+		if errors.Is(err, services.ErrSerialIsEmpty) ||
+			errors.Is(err, services.ErrSerialNotFound) {
+			ErrorHandler(w, http.StatusBadRequest, err)
+			return
+		}
+		ErrorHandler(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SuccessHandler(w, s)
+}
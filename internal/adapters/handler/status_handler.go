@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"warehouse/internal/core/domain"
+	"warehouse/internal/core/services"
+)
+
+func (h *GoodHandler) MoveStockStatus(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	q := r.URL.Query()
+	req := domain.StatusMoveRequest{
+		From:   domain.StockStatus(q.Get("from")),
+		To:     domain.StockStatus(q.Get("to")),
+		Reason: q.Get("reason"),
+	}
+	var err error
+
+	if req.GoodID, err = queryInt(q, "goodID"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.WarehouseID, err = queryInt(q, "warehouseID"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Quantity, err = queryInt(q, "quantity"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// серийные номера передаются повторяющимся параметром serial
+	req.Serials = q["serial"]
+
+	level, err := h.svc.MoveStockStatus(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, services.ErrGoodIDisNegative) ||
+			errors.Is(err, services.ErrWarehouseIDisNegative) ||
+			errors.Is(err, services.ErrUnknownStockStatus) ||
+			errors.Is(err, services.ErrSameStockStatus) ||
+			errors.Is(err, services.ErrQuantityIsNegative) ||
+			errors.Is(err, services.ErrGoodWarehouseIsNotExist) ||
+			errors.Is(err, services.ErrSerialIsEmpty) ||
+			errors.Is(err, services.ErrSerialIsDuplicated) ||
+			errors.Is(err, services.ErrSerialCountMismatch) ||
+			errors.Is(err, services.ErrSerialsIsRequired) ||
+			errors.Is(err, services.ErrGoodIsNotSerialTracked) ||
+			errors.Is(err, services.ErrSerialIsNotAvailable) ||
+			errors.Is(err, services.ErrNotEnoughGoods) {
+			ErrorHandler(w, http.StatusBadRequest, err)
+			return
+		}
+		ErrorHandler(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SuccessHandler(w, level)
+}
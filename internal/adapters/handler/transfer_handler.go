@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"warehouse/internal/core/domain"
+	"warehouse/internal/core/services"
+)
+
+func transferErrorStatus(err error) int {
+	if errors.Is(err, services.ErrGoodIDisNegative) ||
+		errors.Is(err, services.ErrWarehouseIDisNegative) ||
+		errors.Is(err, services.ErrQuantityIsNegative) ||
+		errors.Is(err, services.ErrSameWarehouse) ||
+		errors.Is(err, services.ErrGoodWarehouseIsNotExist) ||
+		errors.Is(err, services.ErrWarehouseIsUnavailable) ||
+		errors.Is(err, services.ErrNotEnoughGoods) ||
+		errors.Is(err, services.ErrTransferIDisNegative) ||
+		errors.Is(err, services.ErrTransferNotFound) ||
+		errors.Is(err, services.ErrTransferIsNotInTransit) ||
+		errors.Is(err, services.ErrLotExpiryMismatch) ||
+		errors.Is(err, services.ErrSerialIsEmpty) ||
+		errors.Is(err, services.ErrSerialIsDuplicated) ||
+		errors.Is(err, services.ErrSerialCountMismatch) ||
+		errors.Is(err, services.ErrSerialsIsRequired) ||
+		errors.Is(err, services.ErrGoodIsNotSerialTracked) ||
+		errors.Is(err, services.ErrBinIDisNegative) ||
+		errors.Is(err, services.ErrBinNotFound) ||
+		errors.Is(err, services.ErrBinIsNotInWarehouse) ||
+		errors.Is(err, services.ErrNotEnoughInBin) ||
+		errors.Is(err, services.ErrSerialIsNotAvailable) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, services.ErrCapacityExceeded) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *GoodHandler) TransferGood(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	q := r.URL.Query()
+	req := domain.TransferRequest{}
+	var err error
+
+	if req.GoodID, err = queryInt(q, "goodID"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.FromWarehouseID, err = queryInt(q, "fromWarehouseID"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.ToWarehouseID, err = queryInt(q, "toWarehouseID"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Quantity, err = queryInt(q, "quantity"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+	if sInTransit := q.Get("inTransit"); sInTransit != "" {
+		if req.InTransit, err = strconv.ParseBool(sInTransit); err != nil {
+			ErrorHandler(w, http.StatusBadRequest, errors.New(fmt.Sprintf(errQueryIsNotBool, "inTransit")))
+			return
+		}
+	}
+
+	if req.FromBinID, err = queryOptionalInt(q, "fromBinID"); err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// серийные номера передаются повторяющимся параметром serial
+	req.Serials = q["serial"]
+
+	t, err := h.svc.Transfer(r.Context(), req)
+	if err != nil {
+		ErrorHandler(w, transferErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, t)
+}
+
+func (h *GoodHandler) ReceiveTransfer(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	id, err := queryInt(r.URL.Query(), "transferID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	t, err := h.svc.ReceiveTransfer(r.Context(), id)
+	if err != nil {
+		ErrorHandler(w, transferErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, t)
+}
+
+func (h *GoodHandler) GetTransfer(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	id, err := queryInt(r.URL.Query(), "transferID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	t, err := h.svc.GetTransfer(r.Context(), id)
+	if err != nil {
+		ErrorHandler(w, transferErrorStatus(err), err)
+		return
+	}
+
+	SuccessHandler(w, t)
+}
@@ -151,3 +151,63 @@ func (h *WarehouseHandler) GetCountGoods(w http.ResponseWriter, r *http.Request)
 		"count": cnt,
 	})
 }
+
+func (h *WarehouseHandler) SetAvailability(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	q := r.URL.Query()
+
+	ID, err := queryInt(q, "warehouseID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	sIsAvailable := q.Get("isAvailable")
+	if sIsAvailable == "" {
+		ErrorHandler(w, http.StatusBadRequest, errors.New(fmt.Sprintf(errQueryIsEmpty, "isAvailable")))
+		return
+	}
+
+	isAvailable, err := strconv.ParseBool(sIsAvailable)
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, errors.New(fmt.Sprintf(errQueryIsNotBool, "isAvailable")))
+		return
+	}
+
+	if err = h.svc.SetAvailability(r.Context(), ID, isAvailable); err != nil {
+		if errors.Is(err, services.ErrWarehouseIDisNegative) || errors.Is(err, services.ErrWarehouseIsNotExist) {
+			ErrorHandler(w, http.StatusBadRequest, err)
+			return
+		}
+		ErrorHandler(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SuccessHandler(w, map[string]interface{}{
+		"id":           ID,
+		"is_available": isAvailable,
+	})
+}
+
+func (h *WarehouseHandler) GetWarehouseCapacity(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+
+	ID, err := queryOptionalInt(r.URL.Query(), "warehouseID")
+	if err != nil {
+		ErrorHandler(w, http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := h.svc.GetWarehouseCapacity(r.Context(), ID)
+	if err != nil {
+		if errors.Is(err, services.ErrWarehouseIDisNegative) || errors.Is(err, services.ErrWarehouseIsNotExist) {
+			ErrorHandler(w, http.StatusBadRequest, err)
+			return
+		}
+		ErrorHandler(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	SuccessHandler(w, report)
+}
@@ -0,0 +1,20 @@
+package notifier
+
+import (
+	"context"
+	"log"
+	"warehouse/internal/core/domain"
+)
+
+// LogNotifier пишет алерты в стандартный лог. Используется, пока не подключена внешняя доставка
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Notify(_ context.Context, alert domain.StockAlert) error {
+	log.Printf("low stock: good %d in warehouse %d has %d free (reorder point %d, target level %d)",
+		alert.GoodID, alert.WarehouseID, alert.Free, alert.ReorderPoint, alert.TargetLevel)
+	return nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"warehouse/internal/core/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// adjustStock меняет count на delta внутри tx. count не может стать меньше reserved,
+// поэтому списать можно только свободные единицы. Серийные номера товара приходуются или списываются вместе с остатком.
+// Оприходовать комплект нельзя: у него нет своего остатка
+func (pg *PostgresConn) adjustStock(ctx context.Context, tx pgx.Tx, req domain.AdjustmentRequest, reference string) (domain.StockLevel, error) {
+	if req.Delta > 0 {
+		if err := pg.checkNotKits(ctx, tx, []domain.GoodQuantity{{GoodID: req.GoodID, Quantity: req.Delta}}); err != nil {
+			return domain.StockLevel{}, err
+		}
+	}
+
+	gw, isExist, err := pg.lockGoodInWarehouse(ctx, tx, req.WarehouseID, req.GoodID)
+	if err != nil {
+		return domain.StockLevel{}, err
+	}
+
+	if !isExist && req.Delta < 0 {
+		return domain.StockLevel{}, ErrFailedCheckGoodInWarehouse
+	}
+
+	if gw.Count+req.Delta < gw.Reserved {
+		return domain.StockLevel{}, ErrBelowReserved
+	}
+
+	// номера оприходуются как принятые или списываются вместе с остатком
+	if req.Delta > 0 {
+		err = pg.registerSerials(ctx, tx, req.GoodID, req.WarehouseID, req.Serials, domain.SerialEventAdjust, reference)
+	} else if req.Delta < 0 {
+		_, err = pg.moveSerials(ctx, tx, serialMove{
+			GoodID:      req.GoodID,
+			WarehouseID: req.WarehouseID,
+			From:        domain.SerialInStock,
+			To:          domain.SerialWrittenOff,
+			Quantity:    -req.Delta,
+			Serials:     req.Serials,
+			Event:       domain.SerialEventAdjust,
+			Reference:   reference,
+		})
+	}
+	if err != nil {
+		return domain.StockLevel{}, err
+	}
+
+	if err = pg.changeStock(ctx, tx, domain.StockMovement{
+		GoodID:      req.GoodID,
+		WarehouseID: req.WarehouseID,
+		Type:        domain.MovementAdjust,
+		CountDelta:  req.Delta,
+		BinID:       req.BinID,
+		Reason:      req.Reason,
+		Reference:   reference,
+	}); err != nil {
+		return domain.StockLevel{}, err
+	}
+
+	return domain.StockLevel{
+		GoodID:      req.GoodID,
+		WarehouseID: req.WarehouseID,
+		Count:       gw.Count + req.Delta,
+		Reserved:    gw.Reserved,
+		Quarantined: gw.Quarantined,
+		Damaged:     gw.Damaged,
+	}, nil
+}
+
+func (pg *PostgresConn) AdjustStock(ctx context.Context, req domain.AdjustmentRequest) (domain.StockLevel, error) {
+	isExist, err := pg.warehouseIsExist(ctx, req.WarehouseID)
+	if err != nil {
+		return domain.StockLevel{}, err
+	}
+	if !isExist {
+		return domain.StockLevel{}, ErrIsNotExist
+	}
+
+	isExist, err = pg.goodIsExist(ctx, req.GoodID)
+	if err != nil {
+		return domain.StockLevel{}, err
+	}
+	if !isExist {
+		return domain.StockLevel{}, ErrIsNotExist
+	}
+
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return domain.StockLevel{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	level, err := pg.adjustStock(ctx, tx, req, "")
+	if err != nil {
+		return domain.StockLevel{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return domain.StockLevel{}, fmt.Errorf("error commit adjustment: %w", err)
+	}
+	return level, nil
+}
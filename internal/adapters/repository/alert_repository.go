@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"warehouse/internal/core/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const setStockThreshold = `INSERT INTO goods_warehouse(warehouse_id, good_id, count, reserved, reorder_point, target_level) VALUES ($1, $2, 0, 0, $3, $4)
+ON CONFLICT (warehouse_id, good_id) DO UPDATE SET reorder_point = EXCLUDED.reorder_point, target_level = EXCLUDED.target_level`
+
+// SetStockThreshold задает точку заказа. Строка goods_warehouse создается с нулевым остатком, если ее еще нет
+func (pg *PostgresConn) SetStockThreshold(ctx context.Context, t domain.StockThreshold) error {
+	isExist, err := pg.warehouseIsExist(ctx, t.WarehouseID)
+	if err != nil {
+		return err
+	}
+	if !isExist {
+		return ErrIsNotExist
+	}
+
+	isExist, err = pg.goodIsExist(ctx, t.GoodID)
+	if err != nil {
+		return err
+	}
+	if !isExist {
+		return ErrIsNotExist
+	}
+
+	if _, err = pg.pool.Exec(ctx, setStockThreshold, t.WarehouseID, t.GoodID, t.ReorderPoint, t.TargetLevel); err != nil {
+		return fmt.Errorf("error set threshold of good %d in warehouse %d: %w", t.GoodID, t.WarehouseID, err)
+	}
+	return nil
+}
+
+const (
+	getStockThreshold = `SELECT count - reserved, reorder_point, target_level FROM goods_warehouse
+WHERE warehouse_id = $1 AND good_id = $2 AND reorder_point IS NOT NULL`
+	createStockAlert = `INSERT INTO stock_alerts(good_id, warehouse_id, free, reorder_point, target_level) VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (good_id, warehouse_id) WHERE resolved_at IS NULL DO NOTHING RETURNING id, created_at`
+	resolveStockAlert = `UPDATE stock_alerts SET resolved_at = now() WHERE good_id = $1 AND warehouse_id = $2 AND resolved_at IS NULL`
+)
+
+// CheckStockThresholds сравнивает свободный остаток с точкой заказа и возвращает только новые алерты.
+// Открытый алерт закрывается, если остаток поднялся выше точки заказа
+func (pg *PostgresConn) CheckStockThresholds(ctx context.Context, keys []domain.StockKey) ([]domain.StockAlert, error) {
+	alerts := make([]domain.StockAlert, 0)
+	for _, k := range keys {
+		a := domain.StockAlert{GoodID: k.GoodID, WarehouseID: k.WarehouseID}
+		err := pg.pool.QueryRow(ctx, getStockThreshold, k.WarehouseID, k.GoodID).Scan(&a.Free, &a.ReorderPoint, &a.TargetLevel)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			}
+			return nil, fmt.Errorf("error get threshold of good %d in warehouse %d: %w", k.GoodID, k.WarehouseID, err)
+		}
+
+		if a.Free > a.ReorderPoint {
+			if _, err = pg.pool.Exec(ctx, resolveStockAlert, k.GoodID, k.WarehouseID); err != nil {
+				return nil, fmt.Errorf("error resolve alert of good %d in warehouse %d: %w", k.GoodID, k.WarehouseID, err)
+			}
+			continue
+		}
+
+		err = pg.pool.QueryRow(ctx, createStockAlert, a.GoodID, a.WarehouseID, a.Free, a.ReorderPoint, a.TargetLevel).
+			Scan(&a.ID, &a.CreatedAt)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				// алерт по этой паре уже открыт
+				continue
+			}
+			return nil, fmt.Errorf("error create alert of good %d in warehouse %d: %w", k.GoodID, k.WarehouseID, err)
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, nil
+}
+
+const getStockAlerts = `SELECT id, good_id, warehouse_id, free, reorder_point, target_level, created_at, resolved_at FROM stock_alerts`
+
+func (pg *PostgresConn) GetStockAlerts(ctx context.Context, filter domain.StockAlertFilter) ([]domain.StockAlert, error) {
+	where := make([]string, 0, 3)
+	args := make([]any, 0, 2)
+	if filter.GoodID > 0 {
+		args = append(args, filter.GoodID)
+		where = append(where, fmt.Sprintf("good_id = $%d", len(args)))
+	}
+	if filter.WarehouseID > 0 {
+		args = append(args, filter.WarehouseID)
+		where = append(where, fmt.Sprintf("warehouse_id = $%d", len(args)))
+	}
+	if filter.OpenOnly {
+		where = append(where, "resolved_at IS NULL")
+	}
+
+	query := getStockAlerts
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY created_at, id"
+
+	rows, err := pg.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error get stock alerts: %w", err)
+	}
+
+	alerts, err := pgx.CollectRows(rows, pgx.RowToStructByPos[domain.StockAlert])
+	if err != nil {
+		return nil, fmt.Errorf("error collect stock alerts: %w", err)
+	}
+	return alerts, nil
+}
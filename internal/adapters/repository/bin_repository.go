@@ -0,0 +1,397 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"warehouse/internal/core/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	checkZone   = `SELECT warehouse_id FROM zones WHERE id = $1`
+	checkAisle  = `SELECT zones.warehouse_id FROM aisles INNER JOIN zones ON aisles.zone_id = zones.id WHERE aisles.id = $1`
+	createZone  = `INSERT INTO zones(warehouse_id, code) VALUES ($1, $2) ON CONFLICT (warehouse_id, code) DO NOTHING RETURNING id`
+	createAisle = `INSERT INTO aisles(zone_id, code) VALUES ($1, $2) ON CONFLICT (zone_id, code) DO NOTHING RETURNING id`
+	createBin   = `INSERT INTO bins(aisle_id, code) VALUES ($1, $2) ON CONFLICT (aisle_id, code) DO NOTHING RETURNING id`
+)
+
+// createLocation проверяет родителя и создает под ним зону, ряд или ячейку с уникальным в пределах родителя кодом.
+// checkParent возвращает склад родителя
+func (pg *PostgresConn) createLocation(ctx context.Context, checkParent, create string, parentID int, code string) (int, int, error) {
+	var warehouseID int
+	if err := pg.pool.QueryRow(ctx, checkParent, parentID).Scan(&warehouseID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, 0, ErrIsNotExist
+		}
+		return 0, 0, fmt.Errorf("error check parent location with id = %d: %w", parentID, err)
+	}
+
+	var id int
+	if err := pg.pool.QueryRow(ctx, create, parentID, code).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, 0, ErrIsExist
+		}
+		return 0, 0, fmt.Errorf("error create location %s: %w", code, err)
+	}
+	return id, warehouseID, nil
+}
+
+// у склада нет своего родителя, поэтому проверяется сам склад
+const checkWarehouseForZone = `SELECT id FROM warehouse WHERE id = $1`
+
+func (pg *PostgresConn) CreateZone(ctx context.Context, warehouseID int, code string) (domain.Zone, error) {
+	id, _, err := pg.createLocation(ctx, checkWarehouseForZone, createZone, warehouseID, code)
+	if err != nil {
+		return domain.Zone{}, err
+	}
+	return domain.Zone{ID: id, WarehouseID: warehouseID, Code: code, Aisles: make([]domain.Aisle, 0)}, nil
+}
+
+func (pg *PostgresConn) CreateAisle(ctx context.Context, zoneID int, code string) (domain.Aisle, error) {
+	id, _, err := pg.createLocation(ctx, checkZone, createAisle, zoneID, code)
+	if err != nil {
+		return domain.Aisle{}, err
+	}
+	return domain.Aisle{ID: id, ZoneID: zoneID, Code: code, Bins: make([]domain.Bin, 0)}, nil
+}
+
+func (pg *PostgresConn) CreateBin(ctx context.Context, aisleID int, code string) (domain.Bin, error) {
+	id, warehouseID, err := pg.createLocation(ctx, checkAisle, createBin, aisleID, code)
+	if err != nil {
+		return domain.Bin{}, err
+	}
+	return domain.Bin{ID: id, AisleID: aisleID, WarehouseID: warehouseID, Code: code}, nil
+}
+
+const (
+	receivingCode        = "RECEIVING"
+	createReceivingZone  = `INSERT INTO zones(warehouse_id, code) VALUES ($1, $2) RETURNING id`
+	createReceivingAisle = `INSERT INTO aisles(zone_id, code) VALUES ($1, $2) RETURNING id`
+	createReceivingBin   = `INSERT INTO bins(aisle_id, code, receiving) VALUES ($1, $2, true) RETURNING id`
+)
+
+// createReceivingLocation создает у нового склада зону, ряд и ячейку приемки
+func createReceivingLocation(ctx context.Context, tx pgx.Tx, warehouseID int) error {
+	var zoneID, aisleID int
+	if err := tx.QueryRow(ctx, createReceivingZone, warehouseID, receivingCode).Scan(&zoneID); err != nil {
+		return fmt.Errorf("error create receiving zone of warehouse with id = %d: %w", warehouseID, err)
+	}
+	if err := tx.QueryRow(ctx, createReceivingAisle, zoneID, receivingCode).Scan(&aisleID); err != nil {
+		return fmt.Errorf("error create receiving aisle of warehouse with id = %d: %w", warehouseID, err)
+	}
+	if _, err := tx.Exec(ctx, createReceivingBin, aisleID, receivingCode); err != nil {
+		return fmt.Errorf("error create receiving bin of warehouse with id = %d: %w", warehouseID, err)
+	}
+	return nil
+}
+
+const (
+	selectBins = `SELECT bins.id, bins.aisle_id, zones.warehouse_id, bins.code, bins.receiving FROM bins
+INNER JOIN aisles ON bins.aisle_id = aisles.id INNER JOIN zones ON aisles.zone_id = zones.id`
+	getBin      = selectBins + ` WHERE bins.id = $1`
+	getBinGoods = `SELECT good_id, quantity FROM bin_stock WHERE bin_id = $1 ORDER BY good_id`
+)
+
+func getBinWithGoods(ctx context.Context, q querier, id int) (domain.Bin, error) {
+	b := domain.Bin{}
+	if err := q.QueryRow(ctx, getBin, id).Scan(&b.ID, &b.AisleID, &b.WarehouseID, &b.Code, &b.Receiving); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Bin{}, ErrNotFound
+		}
+		return domain.Bin{}, fmt.Errorf("error get bin with id = %d: %w", id, err)
+	}
+
+	rows, err := q.Query(ctx, getBinGoods, id)
+	if err != nil {
+		return domain.Bin{}, fmt.Errorf("error get goods of bin with id = %d: %w", id, err)
+	}
+	b.Goods = make([]domain.GoodQuantity, 0)
+	var gq domain.GoodQuantity
+	if _, err = pgx.ForEachRow(rows, []any{&gq.GoodID, &gq.Quantity}, func() error {
+		b.Goods = append(b.Goods, gq)
+		return nil
+	}); err != nil {
+		return domain.Bin{}, fmt.Errorf("error collect goods of bin with id = %d: %w", id, err)
+	}
+	return b, nil
+}
+
+// GetBin возвращает ячейку и ее содержимое
+func (pg *PostgresConn) GetBin(ctx context.Context, id int) (domain.Bin, error) {
+	return getBinWithGoods(ctx, pg.pool, id)
+}
+
+const (
+	addBinStock = `INSERT INTO bin_stock(bin_id, good_id, quantity) VALUES ($1, $2, $3)
+ON CONFLICT (bin_id, good_id) DO UPDATE SET quantity = bin_stock.quantity + EXCLUDED.quantity`
+	deleteBinStock = `DELETE FROM bin_stock WHERE bin_id = $1 AND good_id = $2 AND quantity = $3`
+	takeBinStock   = `UPDATE bin_stock SET quantity = quantity - $3 WHERE bin_id = $1 AND good_id = $2 AND quantity > $3`
+)
+
+// takeFromBin убирает единицы из ячейки, опустевшая строка удаляется
+func takeFromBin(ctx context.Context, tx pgx.Tx, binID, goodID, quantity int) error {
+	tag, err := tx.Exec(ctx, deleteBinStock, binID, goodID, quantity)
+	if err != nil {
+		return fmt.Errorf("error take good %d from bin %d: %w", goodID, binID, err)
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+	if tag, err = tx.Exec(ctx, takeBinStock, binID, goodID, quantity); err != nil {
+		return fmt.Errorf("error take good %d from bin %d: %w", goodID, binID, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotEnoughInBin
+	}
+	return nil
+}
+
+const (
+	getReceivingBin = `SELECT bins.id FROM bins INNER JOIN aisles ON bins.aisle_id = aisles.id INNER JOIN zones ON aisles.zone_id = zones.id
+WHERE zones.warehouse_id = $1 AND bins.receiving`
+	getBinWarehouse = `SELECT zones.warehouse_id FROM bins INNER JOIN aisles ON bins.aisle_id = aisles.id INNER JOIN zones ON aisles.zone_id = zones.id
+WHERE bins.id = $1`
+	getGoodBins = `SELECT bin_stock.bin_id, bin_stock.quantity FROM bin_stock
+INNER JOIN bins ON bin_stock.bin_id = bins.id INNER JOIN aisles ON bins.aisle_id = aisles.id INNER JOIN zones ON aisles.zone_id = zones.id
+WHERE bin_stock.good_id = $1 AND zones.warehouse_id = $2 ORDER BY bins.receiving DESC, bin_stock.bin_id`
+)
+
+// binWarehouse возвращает склад ячейки
+func binWarehouse(ctx context.Context, q querier, binID int) (int, error) {
+	var warehouseID int
+	if err := q.QueryRow(ctx, getBinWarehouse, binID).Scan(&warehouseID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, ErrBinNotFound
+		}
+		return 0, fmt.Errorf("error get warehouse of bin with id = %d: %w", binID, err)
+	}
+	return warehouseID, nil
+}
+
+func receivingBin(ctx context.Context, q querier, warehouseID int) (int, error) {
+	var id int
+	if err := q.QueryRow(ctx, getReceivingBin, warehouseID).Scan(&id); err != nil {
+		return 0, fmt.Errorf("error get receiving bin of warehouse with id = %d: %w", warehouseID, err)
+	}
+	return id, nil
+}
+
+// binTake - сколько единиц движения приходится на ячейку
+type binTake struct {
+	BinID    int
+	Quantity int
+}
+
+// stockBins возвращает ячейки, в которых меняется физический остаток движения. Названная ячейка должна быть
+// на складе движения. Без ячейки приход попадает в ячейку приемки, а расход снимается сначала из ячейки приемки,
+// затем из остальных ячеек товара по порядку id
+func stockBins(ctx context.Context, tx pgx.Tx, m domain.StockMovement, physicalDelta int) ([]binTake, error) {
+	quantity := max(physicalDelta, -physicalDelta)
+	if m.BinID != 0 {
+		warehouseID, err := binWarehouse(ctx, tx, m.BinID)
+		if err != nil {
+			return nil, err
+		}
+		if warehouseID != m.WarehouseID {
+			return nil, ErrBinIsNotInWarehouse
+		}
+		return []binTake{{BinID: m.BinID, Quantity: quantity}}, nil
+	}
+
+	if physicalDelta > 0 {
+		binID, err := receivingBin(ctx, tx, m.WarehouseID)
+		if err != nil {
+			return nil, err
+		}
+		return []binTake{{BinID: binID, Quantity: quantity}}, nil
+	}
+
+	rows, err := tx.Query(ctx, getGoodBins, m.GoodID, m.WarehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("error get bins of good %d in warehouse %d: %w", m.GoodID, m.WarehouseID, err)
+	}
+	bins, err := pgx.CollectRows(rows, pgx.RowToStructByPos[binTake])
+	if err != nil {
+		return nil, fmt.Errorf("error collect bins of good %d in warehouse %d: %w", m.GoodID, m.WarehouseID, err)
+	}
+	return planBinTakes(bins, quantity)
+}
+
+// planBinTakes снимает quantity единиц из ячеек bins по порядку: каждая ячейка отдает все, что в ней есть,
+// пока не наберется quantity
+func planBinTakes(bins []binTake, quantity int) ([]binTake, error) {
+	takes := make([]binTake, 0, 1)
+	for _, b := range bins {
+		if quantity == 0 {
+			break
+		}
+		q := min(quantity, b.Quantity)
+		takes = append(takes, binTake{BinID: b.BinID, Quantity: q})
+		quantity -= q
+	}
+	if quantity > 0 {
+		return nil, ErrNotEnoughInBin
+	}
+	return takes, nil
+}
+
+// PutAway раскладывает в ячейку единицы товара из ячейки приемки ее склада
+func (pg *PostgresConn) PutAway(ctx context.Context, binID, goodID, quantity int) (domain.Bin, error) {
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return domain.Bin{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	b, err := getBinWithGoods(ctx, tx, binID)
+	if err != nil {
+		return domain.Bin{}, err
+	}
+
+	_, isExist, err := pg.lockGoodInWarehouse(ctx, tx, b.WarehouseID, goodID)
+	if err != nil {
+		return domain.Bin{}, err
+	}
+	if !isExist {
+		return domain.Bin{}, ErrFailedCheckGoodInWarehouse
+	}
+
+	receiving, err := receivingBin(ctx, tx, b.WarehouseID)
+	if err != nil {
+		return domain.Bin{}, err
+	}
+	if err = takeFromBin(ctx, tx, receiving, goodID, quantity); err != nil {
+		if errors.Is(err, ErrNotEnoughInBin) {
+			return domain.Bin{}, ErrNotEnoughUnbinned
+		}
+		return domain.Bin{}, err
+	}
+	if _, err = tx.Exec(ctx, addBinStock, binID, goodID, quantity); err != nil {
+		return domain.Bin{}, fmt.Errorf("error put good %d into bin %d: %w", goodID, binID, err)
+	}
+
+	if b, err = getBinWithGoods(ctx, tx, binID); err != nil {
+		return domain.Bin{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return domain.Bin{}, err
+	}
+	return b, nil
+}
+
+// MoveBinStock перемещает единицы между ячейками одного склада, остаток склада не меняется.
+// Возвращает обе ячейки после перемещения
+func (pg *PostgresConn) MoveBinStock(ctx context.Context, req domain.BinMoveRequest) ([]domain.Bin, error) {
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	from, err := getBinWithGoods(ctx, tx, req.FromBinID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := getBinWithGoods(ctx, tx, req.ToBinID)
+	if err != nil {
+		return nil, err
+	}
+	if from.WarehouseID != to.WarehouseID {
+		return nil, ErrBinsInDifferentWarehouses
+	}
+
+	// строка goods_warehouse блокируется, чтобы не разойтись с движениями остатка, которые меняют ячейки
+	if _, _, err = pg.lockGoodInWarehouse(ctx, tx, from.WarehouseID, req.GoodID); err != nil {
+		return nil, err
+	}
+
+	if err = takeFromBin(ctx, tx, req.FromBinID, req.GoodID, req.Quantity); err != nil {
+		return nil, err
+	}
+	if _, err = tx.Exec(ctx, addBinStock, req.ToBinID, req.GoodID, req.Quantity); err != nil {
+		return nil, fmt.Errorf("error put good %d into bin %d: %w", req.GoodID, req.ToBinID, err)
+	}
+
+	if from, err = getBinWithGoods(ctx, tx, req.FromBinID); err != nil {
+		return nil, err
+	}
+	if to, err = getBinWithGoods(ctx, tx, req.ToBinID); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return []domain.Bin{from, to}, nil
+}
+
+const (
+	getZones  = `SELECT id, warehouse_id, code FROM zones WHERE warehouse_id = $1 ORDER BY code`
+	getAisles = `SELECT aisles.id, aisles.zone_id, aisles.code FROM aisles INNER JOIN zones ON aisles.zone_id = zones.id
+WHERE zones.warehouse_id = $1 ORDER BY aisles.code`
+	getWarehouseBins = selectBins + ` WHERE zones.warehouse_id = $1 ORDER BY bins.code`
+)
+
+// GetWarehouseLayout возвращает зоны, ряды и ячейки склада вместе с ячейкой приемки
+func (pg *PostgresConn) GetWarehouseLayout(ctx context.Context, warehouseID int) (domain.WarehouseLayout, error) {
+	isExist, err := pg.warehouseIsExist(ctx, warehouseID)
+	if err != nil {
+		return domain.WarehouseLayout{}, err
+	}
+	if !isExist {
+		return domain.WarehouseLayout{}, ErrNotFound
+	}
+
+	layout := domain.WarehouseLayout{WarehouseID: warehouseID, Zones: make([]domain.Zone, 0)}
+	if layout.ReceivingBinID, err = receivingBin(ctx, pg.pool, warehouseID); err != nil {
+		return domain.WarehouseLayout{}, err
+	}
+
+	rows, err := pg.pool.Query(ctx, getWarehouseBins, warehouseID)
+	if err != nil {
+		return domain.WarehouseLayout{}, fmt.Errorf("error get bins of warehouse with id = %d: %w", warehouseID, err)
+	}
+	binsByAisle := make(map[int][]domain.Bin)
+	var b domain.Bin
+	if _, err = pgx.ForEachRow(rows, []any{&b.ID, &b.AisleID, &b.WarehouseID, &b.Code, &b.Receiving}, func() error {
+		binsByAisle[b.AisleID] = append(binsByAisle[b.AisleID], b)
+		return nil
+	}); err != nil {
+		return domain.WarehouseLayout{}, fmt.Errorf("error collect bins of warehouse with id = %d: %w", warehouseID, err)
+	}
+
+	if rows, err = pg.pool.Query(ctx, getAisles, warehouseID); err != nil {
+		return domain.WarehouseLayout{}, fmt.Errorf("error get aisles of warehouse with id = %d: %w", warehouseID, err)
+	}
+	aislesByZone := make(map[int][]domain.Aisle)
+	var a domain.Aisle
+	if _, err = pgx.ForEachRow(rows, []any{&a.ID, &a.ZoneID, &a.Code}, func() error {
+		a.Bins = binsByAisle[a.ID]
+		if a.Bins == nil {
+			a.Bins = make([]domain.Bin, 0)
+		}
+		aislesByZone[a.ZoneID] = append(aislesByZone[a.ZoneID], a)
+		return nil
+	}); err != nil {
+		return domain.WarehouseLayout{}, fmt.Errorf("error collect aisles of warehouse with id = %d: %w", warehouseID, err)
+	}
+
+	if rows, err = pg.pool.Query(ctx, getZones, warehouseID); err != nil {
+		return domain.WarehouseLayout{}, fmt.Errorf("error get zones of warehouse with id = %d: %w", warehouseID, err)
+	}
+	var z domain.Zone
+	if _, err = pgx.ForEachRow(rows, []any{&z.ID, &z.WarehouseID, &z.Code}, func() error {
+		z.Aisles = aislesByZone[z.ID]
+		if z.Aisles == nil {
+			z.Aisles = make([]domain.Aisle, 0)
+		}
+		layout.Zones = append(layout.Zones, z)
+		return nil
+	}); err != nil {
+		return domain.WarehouseLayout{}, fmt.Errorf("error collect zones of warehouse with id = %d: %w", warehouseID, err)
+	}
+	return layout, nil
+}
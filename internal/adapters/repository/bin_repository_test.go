@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPlanBinTakes(t *testing.T) {
+	// ячейка приемки идет первой, остальные - по id
+	bins := []binTake{
+		{BinID: 7, Quantity: 2},
+		{BinID: 3, Quantity: 4},
+		{BinID: 5, Quantity: 1},
+	}
+
+	tests := []struct {
+		name     string
+		bins     []binTake
+		quantity int
+		want     []binTake
+		wantErr  error
+	}{
+		{
+			name:     "receiving bin covers the whole quantity",
+			bins:     bins,
+			quantity: 2,
+			want:     []binTake{{BinID: 7, Quantity: 2}},
+		},
+		{
+			name:     "quantity spans several bins in order",
+			bins:     bins,
+			quantity: 5,
+			want:     []binTake{{BinID: 7, Quantity: 2}, {BinID: 3, Quantity: 3}},
+		},
+		{
+			name:     "all bins are emptied",
+			bins:     bins,
+			quantity: 7,
+			want:     []binTake{{BinID: 7, Quantity: 2}, {BinID: 3, Quantity: 4}, {BinID: 5, Quantity: 1}},
+		},
+		{
+			name:     "not enough in bins",
+			bins:     bins,
+			quantity: 8,
+			wantErr:  ErrNotEnoughInBin,
+		},
+		{
+			name:     "good is in no bin",
+			bins:     []binTake{},
+			quantity: 1,
+			wantErr:  ErrNotEnoughInBin,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := planBinTakes(tt.bins, tt.quantity)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("takes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"warehouse/internal/core/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// FOR NO KEY UPDATE не конфликтует с блокировками внешних ключей, которые берут вставки в журнал движений
+	lockWarehouseCapacity = `SELECT capacity FROM warehouse WHERE id = $1 FOR NO KEY UPDATE`
+	getUsedVolume         = `SELECT COALESCE(SUM(goods.size * (goods_warehouse.count + goods_warehouse.quarantined + goods_warehouse.damaged)), 0)
+FROM goods_warehouse INNER JOIN goods ON goods_warehouse.good_id = goods.id WHERE goods_warehouse.warehouse_id = $1`
+	getIncomingVolume = `SELECT COALESCE(SUM(goods.size * incoming.quantity), 0)
+FROM unnest($1::INTEGER[], $2::INTEGER[]) AS incoming(good_id, quantity) INNER JOIN goods ON incoming.good_id = goods.id`
+)
+
+// checkCapacity проверяет, что приходящие единицы поместятся на склад.
+// Блокирует строку склада, поэтому вызывается до блокировки строк goods_warehouse этого склада
+func (pg *PostgresConn) checkCapacity(ctx context.Context, tx pgx.Tx, warehouseID int, incoming []domain.GoodQuantity) error {
+	var capacity int
+	if err := tx.QueryRow(ctx, lockWarehouseCapacity, warehouseID).Scan(&capacity); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrIsNotExist
+		}
+		return fmt.Errorf("error lock capacity of warehouse with id = %d: %w", warehouseID, err)
+	}
+	if capacity == 0 {
+		return nil
+	}
+
+	goodIDs := make([]int, 0, len(incoming))
+	quantities := make([]int, 0, len(incoming))
+	for _, l := range incoming {
+		goodIDs = append(goodIDs, l.GoodID)
+		quantities = append(quantities, l.Quantity)
+	}
+
+	var used, volume int
+	if err := tx.QueryRow(ctx, getUsedVolume, warehouseID).Scan(&used); err != nil {
+		return fmt.Errorf("error get used volume of warehouse with id = %d: %w", warehouseID, err)
+	}
+	if err := tx.QueryRow(ctx, getIncomingVolume, goodIDs, quantities).Scan(&volume); err != nil {
+		return fmt.Errorf("error get incoming volume: %w", err)
+	}
+	if used+volume > capacity {
+		return ErrCapacityExceeded
+	}
+	return nil
+}
+
+const getWarehouseCapacity = `SELECT warehouse.id, warehouse.name, warehouse.capacity,
+COALESCE(SUM(goods.size * (goods_warehouse.count + goods_warehouse.quarantined + goods_warehouse.damaged)), 0)
+FROM warehouse LEFT JOIN goods_warehouse ON goods_warehouse.warehouse_id = warehouse.id LEFT JOIN goods ON goods_warehouse.good_id = goods.id`
+
+// GetWarehouseCapacity возвращает занятый и свободный объем склада, при warehouseID = 0 - всех складов
+func (pg *PostgresConn) GetWarehouseCapacity(ctx context.Context, warehouseID int) ([]domain.WarehouseCapacity, error) {
+	query := getWarehouseCapacity
+	args := make([]any, 0, 1)
+	if warehouseID > 0 {
+		isExist, err := pg.warehouseIsExist(ctx, warehouseID)
+		if err != nil {
+			return nil, err
+		}
+		if !isExist {
+			return nil, ErrIsNotExist
+		}
+		args = append(args, warehouseID)
+		query += " WHERE warehouse.id = $1"
+	}
+	query += " GROUP BY warehouse.id ORDER BY warehouse.id"
+
+	rows, err := pg.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error get warehouse capacity: %w", err)
+	}
+	report := make([]domain.WarehouseCapacity, 0)
+	var c domain.WarehouseCapacity
+	if _, err = pgx.ForEachRow(rows, []any{&c.WarehouseID, &c.Name, &c.Capacity, &c.Used}, func() error {
+		c.Free = nil
+		if c.Capacity > 0 {
+			free := c.Capacity - c.Used
+			c.Free = &free
+		}
+		report = append(report, c)
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("error collect warehouse capacity: %w", err)
+	}
+	return report, nil
+}
@@ -0,0 +1,268 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"warehouse/internal/core/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	lockWarehouse          = `SELECT id FROM warehouse WHERE id = $1 FOR UPDATE`
+	checkOpenCountSession  = `SELECT EXISTS(SELECT 1 FROM count_sessions WHERE warehouse_id = $1 AND status = 'open')`
+	createCountSession     = `INSERT INTO count_sessions(warehouse_id, status) VALUES ($1, 'open') RETURNING id, created_at`
+	createCountSessionLine = `INSERT INTO count_session_lines(session_id, good_id, expected)
+SELECT $1, good_id, count FROM goods_warehouse WHERE warehouse_id = $2`
+)
+
+// OpenCountSession открывает инвентаризацию склада и фиксирует ожидаемые остатки.
+// На складе может быть только одна открытая сессия
+func (pg *PostgresConn) OpenCountSession(ctx context.Context, warehouseID int) (domain.CountSession, error) {
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return domain.CountSession{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = tx.QueryRow(ctx, lockWarehouse, warehouseID).Scan(new(int)); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.CountSession{}, ErrIsNotExist
+		}
+		return domain.CountSession{}, fmt.Errorf("error lock warehouse with id = %d: %w", warehouseID, err)
+	}
+
+	var isExist bool
+	if err = tx.QueryRow(ctx, checkOpenCountSession, warehouseID).Scan(&isExist); err != nil {
+		return domain.CountSession{}, fmt.Errorf("error check open count session: %w", err)
+	}
+	if isExist {
+		return domain.CountSession{}, ErrCountSessionIsExist
+	}
+
+	s := domain.CountSession{WarehouseID: warehouseID, Status: domain.CountSessionOpen}
+	if err = tx.QueryRow(ctx, createCountSession, warehouseID).Scan(&s.ID, &s.CreatedAt); err != nil {
+		return domain.CountSession{}, fmt.Errorf("error create count session: %w", err)
+	}
+
+	if _, err = tx.Exec(ctx, createCountSessionLine, s.ID, warehouseID); err != nil {
+		return domain.CountSession{}, fmt.Errorf("error freeze expected quantities: %w", err)
+	}
+
+	if s.Lines, err = selectCountLines(ctx, tx, s.ID); err != nil {
+		return domain.CountSession{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return domain.CountSession{}, err
+	}
+	return s, nil
+}
+
+const (
+	getCountSession  = `SELECT id, warehouse_id, status, created_at, closed_at FROM count_sessions WHERE id = $1`
+	lockCountSession = getCountSession + ` FOR UPDATE`
+	getCountLines    = `SELECT good_id, expected, counted, serials, COALESCE(bin_id, 0) FROM count_session_lines WHERE session_id = $1 ORDER BY good_id`
+	setCountLine     = `INSERT INTO count_session_lines(session_id, good_id, expected, counted, serials, bin_id) VALUES ($1, $2, 0, $3, $4, NULLIF($5, 0))
+ON CONFLICT (session_id, good_id) DO UPDATE SET counted = EXCLUDED.counted, serials = EXCLUDED.serials, bin_id = EXCLUDED.bin_id`
+	setCountSessionStatus = `UPDATE count_sessions SET status = $2, closed_at = now() WHERE id = $1 RETURNING closed_at`
+)
+
+func scanCountSession(row pgx.Row) (domain.CountSession, error) {
+	s := domain.CountSession{}
+	err := row.Scan(&s.ID, &s.WarehouseID, &s.Status, &s.CreatedAt, &s.ClosedAt)
+	return s, err
+}
+
+func selectCountLines(ctx context.Context, q querier, sessionID int) ([]domain.CountLine, error) {
+	rows, err := q.Query(ctx, getCountLines, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("error get lines of count session with id = %d: %w", sessionID, err)
+	}
+
+	lines, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.CountLine, error) {
+		l := domain.CountLine{}
+		if err := row.Scan(&l.GoodID, &l.Expected, &l.Counted, &l.Serials, &l.BinID); err != nil {
+			return domain.CountLine{}, err
+		}
+		if l.Counted != nil {
+			variance := *l.Counted - l.Expected
+			l.Variance = &variance
+		}
+		return l, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error collect lines of count session with id = %d: %w", sessionID, err)
+	}
+	return lines, nil
+}
+
+// lockOpenCountSession блокирует сессию до конца tx и проверяет, что она еще открыта
+func (pg *PostgresConn) lockOpenCountSession(ctx context.Context, tx pgx.Tx, id int) (domain.CountSession, error) {
+	s, err := scanCountSession(tx.QueryRow(ctx, lockCountSession, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.CountSession{}, ErrNotFound
+		}
+		return domain.CountSession{}, fmt.Errorf("error lock count session with id = %d: %w", id, err)
+	}
+	if s.Status != domain.CountSessionOpen {
+		return domain.CountSession{}, ErrCountSessionIsNotOpen
+	}
+	return s, nil
+}
+
+func (pg *PostgresConn) GetCountSession(ctx context.Context, id int) (domain.CountSession, error) {
+	s, err := scanCountSession(pg.pool.QueryRow(ctx, getCountSession, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.CountSession{}, ErrNotFound
+		}
+		return domain.CountSession{}, fmt.Errorf("error get count session with id = %d: %w", id, err)
+	}
+
+	if s.Lines, err = selectCountLines(ctx, pg.pool, id); err != nil {
+		return domain.CountSession{}, err
+	}
+	return s, nil
+}
+
+// SubmitCount записывает пересчитанное количество. Товар, которого не было в снимке,
+// добавляется в сессию с expected = 0, повторная отправка перезаписывает counted, серийные номера и ячейку
+func (pg *PostgresConn) SubmitCount(ctx context.Context, id int, counted []domain.CountedGood) (domain.CountSession, error) {
+	for _, c := range counted {
+		isExist, err := pg.goodIsExist(ctx, c.GoodID)
+		if err != nil {
+			return domain.CountSession{}, err
+		}
+		if !isExist {
+			return domain.CountSession{}, ErrIsNotExist
+		}
+	}
+
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return domain.CountSession{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	s, err := pg.lockOpenCountSession(ctx, tx, id)
+	if err != nil {
+		return domain.CountSession{}, err
+	}
+
+	// комплект не пересчитывается: его остаток - остатки компонентов
+	goods := make([]domain.GoodQuantity, 0, len(counted))
+	for _, c := range counted {
+		goods = append(goods, domain.GoodQuantity{GoodID: c.GoodID, Quantity: c.Counted})
+	}
+	if err = pg.checkNotKits(ctx, tx, goods); err != nil {
+		return domain.CountSession{}, err
+	}
+
+	for _, c := range counted {
+		if c.BinID != 0 {
+			warehouseID, err := binWarehouse(ctx, tx, c.BinID)
+			if err != nil {
+				return domain.CountSession{}, err
+			}
+			if warehouseID != s.WarehouseID {
+				return domain.CountSession{}, ErrBinIsNotInWarehouse
+			}
+		}
+		if _, err = tx.Exec(ctx, setCountLine, id, c.GoodID, c.Counted, c.Serials, c.BinID); err != nil {
+			return domain.CountSession{}, fmt.Errorf("error set counted quantity of good %d: %w", c.GoodID, err)
+		}
+	}
+
+	if s.Lines, err = selectCountLines(ctx, tx, id); err != nil {
+		return domain.CountSession{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return domain.CountSession{}, err
+	}
+	return s, nil
+}
+
+// ApproveCountSession проводит расхождения пересчитанных строк корректировками остатка.
+// Расхождение применяется к текущему count, поэтому резервы и движения за время
+// инвентаризации сохраняются. Если корректировка опустит count ниже reserved,
+// сессия не утверждается целиком
+func (pg *PostgresConn) ApproveCountSession(ctx context.Context, id int, reason string) (domain.CountSession, error) {
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return domain.CountSession{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	s, err := pg.lockOpenCountSession(ctx, tx, id)
+	if err != nil {
+		return domain.CountSession{}, err
+	}
+
+	if s.Lines, err = selectCountLines(ctx, tx, id); err != nil {
+		return domain.CountSession{}, err
+	}
+
+	for _, l := range s.Lines {
+		if l.Variance == nil || *l.Variance == 0 {
+			continue
+		}
+
+		// номера строки - найденные излишки или недостающие единицы, их столько же, сколько расхождение
+		if len(l.Serials) > 0 && len(l.Serials) != max(*l.Variance, -*l.Variance) {
+			return domain.CountSession{}, fmt.Errorf("good %d: %w", l.GoodID, ErrSerialCountMismatch)
+		}
+
+		_, err = pg.adjustStock(ctx, tx, domain.AdjustmentRequest{
+			GoodID:      l.GoodID,
+			WarehouseID: s.WarehouseID,
+			Delta:       *l.Variance,
+			Reason:      reason,
+			Serials:     l.Serials,
+			BinID:       l.BinID,
+		}, countSessionReference(id))
+		if err != nil {
+			return domain.CountSession{}, fmt.Errorf("error adjust good %d: %w", l.GoodID, err)
+		}
+	}
+
+	if err = tx.QueryRow(ctx, setCountSessionStatus, id, domain.CountSessionApproved).Scan(&s.ClosedAt); err != nil {
+		return domain.CountSession{}, fmt.Errorf("error approve count session with id = %d: %w", id, err)
+	}
+	s.Status = domain.CountSessionApproved
+
+	if err = tx.Commit(ctx); err != nil {
+		return domain.CountSession{}, err
+	}
+	return s, nil
+}
+
+func (pg *PostgresConn) CancelCountSession(ctx context.Context, id int) (domain.CountSession, error) {
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return domain.CountSession{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	s, err := pg.lockOpenCountSession(ctx, tx, id)
+	if err != nil {
+		return domain.CountSession{}, err
+	}
+
+	if err = tx.QueryRow(ctx, setCountSessionStatus, id, domain.CountSessionCancelled).Scan(&s.ClosedAt); err != nil {
+		return domain.CountSession{}, fmt.Errorf("error cancel count session with id = %d: %w", id, err)
+	}
+	s.Status = domain.CountSessionCancelled
+
+	if s.Lines, err = selectCountLines(ctx, tx, id); err != nil {
+		return domain.CountSession{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return domain.CountSession{}, err
+	}
+	return s, nil
+}
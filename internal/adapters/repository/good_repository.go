@@ -4,19 +4,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"warehouse/internal/core/domain"
 
 	"github.com/jackc/pgx/v5"
 	"golang.org/x/sync/errgroup"
 )
 
-const getGoodById = `SELECT name, size, id FROM goods WHERE id = $1`
+const getGoodById = `SELECT name, size, id, serial_tracked FROM goods WHERE id = $1`
 
 func (pg *PostgresConn) goodIsExist(ctx context.Context, id int) (bool, error) {
 	row := pg.pool.QueryRow(ctx, getGoodById, id)
 
 	good := domain.Good{}
-	if err := row.Scan(&good.Name, &good.Size, &good.ID); err != nil {
+	if err := row.Scan(&good.Name, &good.Size, &good.ID, &good.SerialTracked); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return false, nil
 		}
@@ -29,7 +30,7 @@ func (pg *PostgresConn) GetGood(ctx context.Context, id int) (domain.Good, error
 	row := pg.pool.QueryRow(ctx, getGoodById, id)
 
 	good := domain.Good{}
-	if err := row.Scan(&good.Name, &good.Size, &good.ID); err != nil {
+	if err := row.Scan(&good.Name, &good.Size, &good.ID, &good.SerialTracked); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return domain.Good{}, ErrNotFound
 		}
@@ -43,10 +44,21 @@ func (pg *PostgresConn) GetGood(ctx context.Context, id int) (domain.Good, error
 
 	good.Warehouses = w
 
+	good.Components, err = getKitComponents(ctx, pg.pool, id)
+	if err != nil {
+		return domain.Good{}, err
+	}
+	if len(good.Components) > 0 {
+		good.KitAvailability, err = pg.getKitAvailability(ctx, id)
+		if err != nil {
+			return domain.Good{}, err
+		}
+	}
+
 	return good, nil
 }
 
-const getWarehousesByGoodId = `SELECT warehouse.name, is_available, count, reserved FROM goods INNER JOIN goods_warehouse ON goods.id = goods_warehouse.good_id INNER JOIN warehouse ON goods_warehouse.warehouse_id = warehouse.id WHERE goods.id = $1`
+const getWarehousesByGoodId = `SELECT warehouse.name, is_available, count, reserved, quarantined, damaged FROM goods INNER JOIN goods_warehouse ON goods.id = goods_warehouse.good_id INNER JOIN warehouse ON goods_warehouse.warehouse_id = warehouse.id WHERE goods.id = $1`
 
 func (pg *PostgresConn) getWarehousesByGoodId(ctx context.Context, id int) ([]domain.WarehouseGoods, error) {
 	rows, err := pg.pool.Query(ctx, getWarehousesByGoodId, id)
@@ -61,7 +73,7 @@ func (pg *PostgresConn) getWarehousesByGoodId(ctx context.Context, id int) ([]do
 	for rows.Next() {
 		w := domain.WarehouseGoods{}
 
-		if err = rows.Scan(&w.WarehouseName, &w.IsAvailable, &w.Count, &w.Reserved); err != nil {
+		if err = rows.Scan(&w.WarehouseName, &w.IsAvailable, &w.Count, &w.Reserved, &w.Quarantined, &w.Damaged); err != nil {
 			return nil, fmt.Errorf("error scan from rows: %w", err)
 		}
 
@@ -75,7 +87,32 @@ func (pg *PostgresConn) getWarehousesByGoodId(ctx context.Context, id int) ([]do
 	return ws, nil
 }
 
-const createGood = `INSERT INTO goods VALUES ($1,$2,$3)`
+const getFreeStock = `SELECT warehouse.id, count - reserved, priority FROM goods_warehouse INNER JOIN warehouse ON goods_warehouse.warehouse_id = warehouse.id WHERE good_id = $1 AND is_available AND count > reserved ORDER BY warehouse.id`
+
+// GetFreeStock возвращает свободный остаток товара на всех доступных складах, где он есть
+func (pg *PostgresConn) GetFreeStock(ctx context.Context, goodID int) ([]domain.WarehouseStock, error) {
+	components, err := getKitComponents(ctx, pg.pool, goodID)
+	if err != nil {
+		return nil, err
+	}
+	if len(components) > 0 {
+		return pg.getKitFreeStock(ctx, goodID)
+	}
+
+	rows, err := pg.pool.Query(ctx, getFreeStock, goodID)
+	if err != nil {
+		return nil, fmt.Errorf("error get free stock of good with id = %d: %w", goodID, err)
+	}
+
+	stocks, err := pgx.CollectRows(rows, pgx.RowToStructByPos[domain.WarehouseStock])
+	if err != nil {
+		return nil, fmt.Errorf("error scan from rows: %w", err)
+	}
+
+	return stocks, nil
+}
+
+const createGood = `INSERT INTO goods(name, size, id, serial_tracked) VALUES ($1,$2,$3,$4)`
 
 func (pg *PostgresConn) CreateGood(ctx context.Context, good domain.Good) error {
 	goodIsExist, err := pg.goodIsExist(ctx, good.ID)
@@ -86,14 +123,14 @@ func (pg *PostgresConn) CreateGood(ctx context.Context, good domain.Good) error
 		return ErrIsExist
 	}
 
-	if _, err = pg.pool.Exec(ctx, createGood, good.Name, good.Size, good.ID); err != nil {
+	if _, err = pg.pool.Exec(ctx, createGood, good.Name, good.Size, good.ID, good.SerialTracked); err != nil {
 		return fmt.Errorf("error create good: %w", err)
 	}
 
 	return nil
 }
 
-const updateGood = `UPDATE goods SET name = $1, size = $2 WHERE id = $3`
+const updateGood = `UPDATE goods SET name = $1, size = $2, serial_tracked = $4 WHERE id = $3`
 
 func (pg *PostgresConn) UpdateGood(ctx context.Context, good domain.Good) error {
 	goodIsExist, err := pg.goodIsExist(ctx, good.ID)
@@ -104,7 +141,7 @@ func (pg *PostgresConn) UpdateGood(ctx context.Context, good domain.Good) error
 		return ErrIsNotExist
 	}
 
-	if _, err = pg.pool.Exec(ctx, updateGood, good.Name, good.Size, good.ID); err != nil {
+	if _, err = pg.pool.Exec(ctx, updateGood, good.Name, good.Size, good.ID, good.SerialTracked); err != nil {
 		return fmt.Errorf("error update good: %w", err)
 	}
 
@@ -129,72 +166,127 @@ func (pg *PostgresConn) DeleteGood(ctx context.Context, id int) error {
 	return nil
 }
 
-const checkGoodInWarehouse = `SELECT * FROM goods_warehouse WHERE warehouse_id = $1 AND good_id = $2 FOR UPDATE`
+const lockGoodInWarehouse = `SELECT id, warehouse_id, good_id, count, reserved, quarantined, damaged FROM goods_warehouse WHERE warehouse_id = $1 AND good_id = $2 FOR UPDATE`
 
-var (
-	errCannotReserve = errors.New("all goods is reserved")
-)
-
-func (pg *PostgresConn) checkGoodInWarehouse(ctx context.Context, tx pgx.Tx, pair domain.PairGoodWarehouse) (bool, error) {
-	row := tx.QueryRow(ctx, checkGoodInWarehouse, pair.WarehouseID, pair.GoodID)
+type goodInWarehouse struct {
+	ID          int
+	WarehouseID int
+	GoodID      int
+	Count       int
+	Reserved    int
+	Quarantined int
+	Damaged     int
+}
 
-	gw := struct {
-		ID            int
-		WarehouseName string
-		GoodID        int
-		Count         int
-		Reserved      int
-	}{}
+func (pg *PostgresConn) lockGoodInWarehouse(ctx context.Context, tx pgx.Tx, warehouseID, goodID int) (goodInWarehouse, bool, error) {
+	row := tx.QueryRow(ctx, lockGoodInWarehouse, warehouseID, goodID)
 
-	if err := row.Scan(&gw.ID, &gw.WarehouseName, &gw.GoodID, &gw.Count, &gw.Reserved); err != nil {
+	gw := goodInWarehouse{}
+	if err := row.Scan(&gw.ID, &gw.WarehouseID, &gw.GoodID, &gw.Count, &gw.Reserved, &gw.Quarantined, &gw.Damaged); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return false, nil
+			return goodInWarehouse{}, false, nil
 		}
-		return false, fmt.Errorf("error check good in warehouse: %w", err)
+		return goodInWarehouse{}, false, fmt.Errorf("error check good in warehouse: %w", err)
 	}
 
-	if gw.Count == gw.Reserved {
-		return true, errCannotReserve
+	return gw, true, nil
+}
+
+// reservePair резервирует одну пару внутри tx и записывает строку резервации,
+// в pair.Serials записываются закрепленные серийные номера.
+// Ошибки из isPairError относятся только к этой паре,
+// остальные ошибки означают, что транзакция больше непригодна
+func (pg *PostgresConn) reservePair(ctx context.Context, tx pgx.Tx, reservationID int, pair *domain.PairGoodWarehouse) error {
+	components, err := getKitComponents(ctx, tx, pair.GoodID)
+	if err != nil {
+		return err
 	}
-	return true, nil
+	if len(components) > 0 {
+		return pg.reserveKit(ctx, tx, reservationID, pair, components)
+	}
+	return pg.reserveGood(ctx, tx, reservationID, pair)
 }
 
-const reserve = `UPDATE goods_warehouse SET reserved = reserved + 1 WHERE warehouse_id = $1 AND good_id = $2`
+// reserveGood резервирует обычный товар на складе пары
+func (pg *PostgresConn) reserveGood(ctx context.Context, tx pgx.Tx, reservationID int, pair *domain.PairGoodWarehouse) error {
+	if err := shareAvailableWarehouse(ctx, tx, pair.WarehouseID); err != nil {
+		return err
+	}
+
+	gw, isExist, err := pg.lockGoodInWarehouse(ctx, tx, pair.WarehouseID, pair.GoodID)
+	if err != nil {
+		return err
+	}
+
+	if !isExist {
+		return ErrFailedCheckGoodInWarehouse
+	}
+
+	if gw.Count-gw.Reserved < pair.Quantity {
+		return ErrNotEnoughGoods
+	}
+
+	serials, err := pg.reserveSerials(ctx, tx, reservationID, *pair)
+	if err != nil {
+		return err
+	}
+
+	if err = pg.reserveLots(ctx, tx, reservationID, gw, pair.Quantity); err != nil {
+		return err
+	}
+
+	if err = pg.changeStock(ctx, tx, domain.StockMovement{
+		GoodID:        pair.GoodID,
+		WarehouseID:   pair.WarehouseID,
+		Type:          domain.MovementReserve,
+		ReservedDelta: pair.Quantity,
+		Reference:     reservationReference(reservationID),
+	}); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(ctx, createReservationLine, reservationID, pair.GoodID, pair.WarehouseID, pair.Quantity); err != nil {
+		return fmt.Errorf("error create reservation line: %w", err)
+	}
+	pair.Serials = serials
+	return nil
+}
+
+func isPairError(err error) bool {
+	return errors.Is(err, ErrFailedCheckGoodInWarehouse) ||
+		errors.Is(err, ErrWarehouseIsUnavailable) ||
+		errors.Is(err, ErrNotEnoughGoods) ||
+		errors.Is(err, ErrSerialIsNotAvailable)
+}
 
-func (pg *PostgresConn) Reservation(ctx context.Context, pairs []domain.PairGoodWarehouse) (domain.MetaInfoReservation, error) {
+func (pg *PostgresConn) Reservation(ctx context.Context, req domain.ReservationRequest) (domain.MetaInfoReservation, error) {
+	if req.Atomic {
+		return pg.reservationAtomic(ctx, req)
+	}
+	if _, ok := domain.IdempotencyClaimFromContext(ctx); ok {
+		// ответ сохраняется в транзакции операции, поэтому все пары резервируются в одной транзакции
+		return pg.reservationInTx(ctx, req)
+	}
+
+	reservationID, err := pg.createReservation(ctx, pg.pool, req.OrderRef, req.TTL)
+	if err != nil {
+		return domain.MetaInfoReservation{}, err
+	}
 	ans := domain.MetaInfoReservation{
-		ReservedPairs:    make([]domain.PairGoodWarehouse, 0, len(pairs)),
+		ReservationID:    reservationID,
+		ReservedPairs:    make([]domain.PairGoodWarehouse, 0, len(req.Pairs)),
 		ErrorReservation: make([]domain.PairGoodWarehouse, 0),
 	}
-	chReserved := AsyncWriteResult(&(ans.ReservedPairs))
-	chErr := AsyncWriteResult(&(ans.ErrorReservation))
+	chReserved, doneReserved := AsyncWriteResult(&(ans.ReservedPairs))
+	chErr, doneErr := AsyncWriteResult(&(ans.ErrorReservation))
 	g, gCtx := errgroup.WithContext(ctx)
-	for _, pair := range pairs {
+	for _, pair := range req.Pairs {
 		g.Go(func() error {
 			tx, err := pg.pool.BeginTx(gCtx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
 			if err != nil {
 				return err
 			}
 			defer tx.Rollback(gCtx)
-			resCheck, err := pg.checkGoodInWarehouse(gCtx, tx, pair)
-			if err != nil {
-				if errors.Is(err, errCannotReserve) {
-					pair.Error = ErrReserve
-					chErr <- pair
-					return nil
-				}
-				pair.Error = err
-				chErr <- pair
-				return nil
-			}
-
-			if !resCheck {
-				pair.Error = ErrFailedCheckGoodInWarehouse
-				chErr <- pair
-				return nil
-			}
-
-			if _, err = tx.Exec(ctx, reserve, pair.WarehouseID, pair.GoodID); err != nil {
+			if err = pg.reservePair(gCtx, tx, reservationID, &pair); err != nil {
 				pair.Error = err
 				chErr <- pair
 				return nil
@@ -208,60 +300,213 @@ func (pg *PostgresConn) Reservation(ctx context.Context, pairs []domain.PairGood
 			return nil
 		})
 	}
-	if err := g.Wait(); err != nil {
-		return domain.MetaInfoReservation{}, err
-	}
+	err = g.Wait()
 	close(chReserved)
 	close(chErr)
+	<-doneReserved
+	<-doneErr
+	if err != nil {
+		return domain.MetaInfoReservation{}, err
+	}
+	if len(ans.ReservedPairs) == 0 {
+		// ни одна пара не зарезервирована, пустая резервация никому не нужна
+		if err = pg.deleteReservation(ctx, reservationID); err != nil {
+			return domain.MetaInfoReservation{}, err
+		}
+		ans.ReservationID = 0
+	}
 	return ans, nil
 }
 
-func AsyncWriteResult(ans *[]domain.PairGoodWarehouse) chan<- domain.PairGoodWarehouse {
+// reservationInTx резервирует пары как Reservation, но в одной транзакции
+// и сохраняет в ней ответ по ключу идемпотентности
+func (pg *PostgresConn) reservationInTx(ctx context.Context, req domain.ReservationRequest) (domain.MetaInfoReservation, error) {
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return domain.MetaInfoReservation{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	reservationID, err := pg.createReservation(ctx, tx, req.OrderRef, req.TTL)
+	if err != nil {
+		return domain.MetaInfoReservation{}, err
+	}
+
+	reserved, failed, err := pairsInTx(ctx, tx, req.Pairs, func(ctx context.Context, tx pgx.Tx, pair *domain.PairGoodWarehouse) error {
+		return pg.reservePair(ctx, tx, reservationID, pair)
+	})
+	if err != nil {
+		return domain.MetaInfoReservation{}, err
+	}
+	ans := domain.MetaInfoReservation{
+		ReservationID:    reservationID,
+		ReservedPairs:    reserved,
+		ErrorReservation: failed,
+	}
+	if len(ans.ReservedPairs) == 0 {
+		if _, err = tx.Exec(ctx, deleteReservation, reservationID); err != nil {
+			return domain.MetaInfoReservation{}, fmt.Errorf("error delete reservation with id = %d: %w", reservationID, err)
+		}
+		ans.ReservationID = 0
+	}
+
+	if err = pg.saveIdempotencyResponse(ctx, tx, ans); err != nil {
+		return domain.MetaInfoReservation{}, err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return domain.MetaInfoReservation{}, err
+	}
+	return ans, nil
+}
+
+// pairsInTx выполняет do для каждой пары в одной транзакции, каждую пару под своей точкой сохранения,
+// поэтому ошибка пары откатывает только ее. Пары обрабатываются в порядке (warehouse_id, good_id),
+// чтобы две такие транзакции блокировали строки goods_warehouse в одном порядке
+func pairsInTx(ctx context.Context, tx pgx.Tx, pairs []domain.PairGoodWarehouse,
+	do func(ctx context.Context, tx pgx.Tx, pair *domain.PairGoodWarehouse) error) ([]domain.PairGoodWarehouse, []domain.PairGoodWarehouse, error) {
+	sorted := make([]domain.PairGoodWarehouse, len(pairs))
+	copy(sorted, pairs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].WarehouseID != sorted[j].WarehouseID {
+			return sorted[i].WarehouseID < sorted[j].WarehouseID
+		}
+		return sorted[i].GoodID < sorted[j].GoodID
+	})
+
+	done := make([]domain.PairGoodWarehouse, 0, len(pairs))
+	failed := make([]domain.PairGoodWarehouse, 0)
+	for _, pair := range sorted {
+		sp, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err = do(ctx, sp, &pair); err != nil {
+			if errRollback := sp.Rollback(ctx); errRollback != nil {
+				return nil, nil, errRollback
+			}
+			pair.Error = err
+			failed = append(failed, pair)
+			continue
+		}
+		if err = sp.Commit(ctx); err != nil {
+			return nil, nil, err
+		}
+		done = append(done, pair)
+	}
+	return done, failed, nil
+}
+
+// reservationAtomic резервирует все пары в одной транзакции: либо все, либо ничего.
+// Комплекты заранее раскладываются на компоненты, и все строки goods_warehouse блокируются
+// одним проходом в порядке (warehouse_id, good_id), поэтому две атомарные резервации
+// не могут заблокировать друг друга. Пары, пришедшие с уже выставленной Error
+// (не прошли валидацию в сервисе), считаются неуспешными и тоже отменяют всю резервацию
+func (pg *PostgresConn) reservationAtomic(ctx context.Context, req domain.ReservationRequest) (domain.MetaInfoReservation, error) {
+	pairs := make([]domain.PairGoodWarehouse, len(req.Pairs))
+	copy(pairs, req.Pairs)
+
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return domain.MetaInfoReservation{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	lines, err := expandKits(ctx, tx, pairs)
+	if err != nil {
+		return domain.MetaInfoReservation{}, err
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		if lines[i].pair.WarehouseID != lines[j].pair.WarehouseID {
+			return lines[i].pair.WarehouseID < lines[j].pair.WarehouseID
+		}
+		return lines[i].pair.GoodID < lines[j].pair.GoodID
+	})
+
+	reservationID, err := pg.createReservation(ctx, tx, req.OrderRef, req.TTL)
+	if err != nil {
+		return domain.MetaInfoReservation{}, err
+	}
+
+	for _, l := range lines {
+		if pairs[l.pairIndex].Error != nil {
+			// пара уже не прошла, остальные ее компоненты не нужны
+			continue
+		}
+		if err = pg.reserveGood(ctx, tx, reservationID, &l.pair); err != nil {
+			if !isPairError(err) {
+				return domain.MetaInfoReservation{}, err
+			}
+			pairs[l.pairIndex].Error = err
+			continue
+		}
+		if l.pair.GoodID == pairs[l.pairIndex].GoodID {
+			pairs[l.pairIndex].Serials = l.pair.Serials
+		}
+	}
+
+	ans := domain.MetaInfoReservation{
+		ReservationID:    reservationID,
+		ReservedPairs:    make([]domain.PairGoodWarehouse, 0, len(pairs)),
+		ErrorReservation: make([]domain.PairGoodWarehouse, 0),
+	}
+	for _, pair := range pairs {
+		if pair.Error != nil {
+			ans.ErrorReservation = append(ans.ErrorReservation, pair)
+			continue
+		}
+		ans.ReservedPairs = append(ans.ReservedPairs, pair)
+	}
+
+	if len(ans.ErrorReservation) > 0 || len(ans.ReservedPairs) == 0 {
+		// откатываем транзакцию целиком, включая саму резервацию.
+		// Зафиксировать нечего, ответ по ключу идемпотентности сохранит сервис
+		return domain.MetaInfoReservation{
+			ReservedPairs:    make([]domain.PairGoodWarehouse, 0),
+			ErrorReservation: ans.ErrorReservation,
+		}, nil
+	}
+
+	if err = pg.saveIdempotencyResponse(ctx, tx, ans); err != nil {
+		return domain.MetaInfoReservation{}, err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return domain.MetaInfoReservation{}, err
+	}
+	return ans, nil
+}
+
+// AsyncWriteResult собирает значения из канала в ans. Второй канал закрывается,
+// когда канал для записи закрыт и все значения дописаны в ans
+func AsyncWriteResult(ans *[]domain.PairGoodWarehouse) (chan<- domain.PairGoodWarehouse, <-chan struct{}) {
 	ch := make(chan domain.PairGoodWarehouse)
+	done := make(chan struct{})
 	go func() {
+		defer close(done)
 		for v := range ch {
 			*ans = append(*ans, v)
 		}
 	}()
-	return ch
+	return ch, done
 }
 
-const releaseReservation = `UPDATE goods_warehouse SET reserved = 0 WHERE warehouse_id = $1 AND good_id = $2`
-
+// ReleaseReservation снимает резерв по парам. Каждая пара снимается со своей резервации
+// в отдельной транзакции, ошибки по парам возвращаются в ErrorRelease
 func (pg *PostgresConn) ReleaseReservation(ctx context.Context, pairs []domain.PairGoodWarehouse) (domain.MetaInfoReleaseReservation, error) {
+	if _, ok := domain.IdempotencyClaimFromContext(ctx); ok {
+		// ответ сохраняется в транзакции операции, поэтому все пары снимаются в одной транзакции
+		return pg.releaseReservationInTx(ctx, pairs)
+	}
+
 	g, gCtx := errgroup.WithContext(ctx)
 	ans := domain.MetaInfoReleaseReservation{
 		ReleasedReservations: make([]domain.PairGoodWarehouse, 0, len(pairs)),
 		ErrorRelease:         make([]domain.PairGoodWarehouse, 0),
 	}
-	chReleased := AsyncWriteResult(&(ans.ReleasedReservations))
-	chErr := AsyncWriteResult(&(ans.ErrorRelease))
+	chReleased, doneReleased := AsyncWriteResult(&(ans.ReleasedReservations))
+	chErr, doneErr := AsyncWriteResult(&(ans.ErrorRelease))
 	for _, pair := range pairs {
 		g.Go(func() error {
-			tx, err := pg.pool.BeginTx(gCtx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
-			if err != nil {
-				return err
-			}
-			defer tx.Rollback(gCtx)
-			resCheck, err := pg.checkGoodInWarehouse(ctx, tx, pair)
-			if err != nil && !errors.Is(err, errCannotReserve) {
-				pair.Error = err
-				chErr <- pair
-				return nil
-			}
-
-			if !resCheck {
-				pair.Error = ErrFailedCheckGoodInWarehouse
-				chErr <- pair
-				return nil
-			}
-
-			if _, err = pg.pool.Exec(ctx, releaseReservation, pair.WarehouseID, pair.GoodID); err != nil {
-				pair.Error = err
-				chErr <- pair
-				return nil
-			}
-			if err = tx.Commit(gCtx); err != nil {
+			if err := pg.releasePair(gCtx, &pair); err != nil {
 				pair.Error = err
 				chErr <- pair
 				return nil
@@ -270,31 +515,46 @@ func (pg *PostgresConn) ReleaseReservation(ctx context.Context, pairs []domain.P
 			return nil
 		})
 	}
-	close(chErr)
+	err := g.Wait()
 	close(chReleased)
+	close(chErr)
+	<-doneReleased
+	<-doneErr
+	if err != nil {
+		return domain.MetaInfoReleaseReservation{}, err
+	}
 	return ans, nil
 }
 
-const checkExistGoodOnWarehouse = `SELECT 1 FROM goods_warehouse WHERE good_id = $1 AND warehouse_id = $2`
-
-func (pg *PostgresConn) checkIsExistGoodOnWarehouse(ctx context.Context, goodID, warehouseID int) (bool, error) {
-	row := pg.pool.QueryRow(ctx, checkExistGoodOnWarehouse, goodID, warehouseID)
+// releaseReservationInTx снимает резерв по парам как ReleaseReservation, но в одной транзакции
+// и сохраняет в ней ответ по ключу идемпотентности
+func (pg *PostgresConn) releaseReservationInTx(ctx context.Context, pairs []domain.PairGoodWarehouse) (domain.MetaInfoReleaseReservation, error) {
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return domain.MetaInfoReleaseReservation{}, err
+	}
+	defer tx.Rollback(ctx)
 
-	if err := row.Scan(new(int)); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return false, nil
-		}
-		return false, fmt.Errorf("error check exist good on warehouse: %w", err)
+	released, failed, err := pairsInTx(ctx, tx, pairs, pg.releasePairInTx)
+	if err != nil {
+		return domain.MetaInfoReleaseReservation{}, err
+	}
+	ans := domain.MetaInfoReleaseReservation{
+		ReleasedReservations: released,
+		ErrorRelease:         failed,
 	}
 
-	return true, nil
+	if err = pg.saveIdempotencyResponse(ctx, tx, ans); err != nil {
+		return domain.MetaInfoReleaseReservation{}, err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return domain.MetaInfoReleaseReservation{}, err
+	}
+	return ans, nil
 }
 
-const addGoodOnWarehouseExist = `UPDATE goods_warehouse SET count = count + $1 WHERE warehouse_id = $2 AND good_id = $3`
-const addGoodOnWarehouseNotExist = `INSERT INTO goods_warehouse(warehouse_id, good_id, count, reserved) VALUES ($1, $2, $3, 0)`
-
-func (pg *PostgresConn) AddGoodOnWarehouse(ctx context.Context, goodID, warehouseID, count int) error {
-	isExist, err := pg.warehouseIsExist(ctx, warehouseID)
+func (pg *PostgresConn) AddGoodOnWarehouse(ctx context.Context, goodID, warehouseID, count int, lot *domain.LotRef, serials []string) error {
+	isExist, err := pg.goodIsExist(ctx, goodID)
 	if err != nil {
 		return err
 	}
@@ -303,29 +563,67 @@ func (pg *PostgresConn) AddGoodOnWarehouse(ctx context.Context, goodID, warehous
 		return ErrIsNotExist
 	}
 
-	isExist, err = pg.goodIsExist(ctx, goodID)
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback(ctx)
 
-	if !isExist {
-		return ErrIsNotExist
+	if err = lockAvailableWarehouse(ctx, tx, warehouseID); err != nil {
+		return err
 	}
 
-	isExist, err = pg.checkIsExistGoodOnWarehouse(ctx, goodID, warehouseID)
+	incoming := []domain.GoodQuantity{{GoodID: goodID, Quantity: count}}
+	if err = pg.checkCapacity(ctx, tx, warehouseID, incoming); err != nil {
+		return err
+	}
 
-	if err != nil {
+	if err = pg.checkNotKits(ctx, tx, incoming); err != nil {
 		return err
 	}
-	if isExist {
-		if _, err = pg.pool.Exec(ctx, addGoodOnWarehouseExist, count, warehouseID, goodID); err != nil {
-			return err
-		}
-	} else {
-		if _, err = pg.pool.Exec(ctx, addGoodOnWarehouseNotExist, goodID, warehouseID, count); err != nil {
+
+	if err = pg.changeStock(ctx, tx, domain.StockMovement{
+		GoodID:      goodID,
+		WarehouseID: warehouseID,
+		Type:        domain.MovementReceipt,
+		CountDelta:  count,
+	}); err != nil {
+		return err
+	}
+
+	if lot != nil {
+		if err = pg.addLot(ctx, tx, goodID, warehouseID, *lot, count); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	if err = pg.registerSerials(ctx, tx, goodID, warehouseID, serials, domain.SerialEventReceipt, ""); err != nil {
+		return err
+	}
+
+	if err = pg.saveIdempotencyResponse(ctx, tx, struct{}{}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+const getGoodSizes = `SELECT id, size FROM goods WHERE id = ANY($1)`
+
+// GetGoodSizes возвращает размеры товаров по id, отсутствующих товаров в ответе нет
+func (pg *PostgresConn) GetGoodSizes(ctx context.Context, goodIDs []int) (map[int]int, error) {
+	rows, err := pg.pool.Query(ctx, getGoodSizes, goodIDs)
+	if err != nil {
+		return nil, fmt.Errorf("error get sizes of goods: %w", err)
+	}
+
+	sizes := make(map[int]int, len(goodIDs))
+	var id, size int
+	if _, err = pgx.ForEachRow(rows, []any{&id, &size}, func() error {
+		sizes[id] = size
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("error collect sizes of goods: %w", err)
+	}
+	return sizes, nil
 }
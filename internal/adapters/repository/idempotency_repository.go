@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"warehouse/internal/core/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// claimIdempotencyKey вставляет ключ без ответа с арендой на lease. Если ключ уже есть, но старше окна
+// или брошен без ответа после истечения аренды, он перезаписывается, как будто его не было
+const claimIdempotencyKey = `INSERT INTO idempotency_keys(key, operation, request_hash, response, created_at, token, locked_until)
+VALUES ($1, $2, $3, NULL, now(), $5, now() + make_interval(secs => $6))
+ON CONFLICT (key, operation) DO UPDATE SET request_hash = EXCLUDED.request_hash, response = NULL, created_at = now(),
+token = EXCLUDED.token, locked_until = EXCLUDED.locked_until
+WHERE idempotency_keys.created_at < now() - make_interval(secs => $4)
+OR (idempotency_keys.response IS NULL AND idempotency_keys.locked_until < now())
+RETURNING key`
+
+const getIdempotencyKey = `SELECT request_hash, response FROM idempotency_keys WHERE key = $1 AND operation = $2`
+
+func newIdempotencyToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ClaimIdempotencyKey занимает ключ под операцию на время lease. Если ключ свободен, возвращается
+// токен занятого ключа: вызывающий выполняет операцию, и ответ сохраняется по токену.
+// Если по ключу уже есть ответ, он возвращается с пустым токеном
+func (pg *PostgresConn) ClaimIdempotencyKey(ctx context.Context, key, operation, requestHash string, window, lease time.Duration) ([]byte, string, error) {
+	token, err := newIdempotencyToken()
+	if err != nil {
+		return nil, "", fmt.Errorf("error generate idempotency token: %w", err)
+	}
+
+	err = pg.pool.QueryRow(ctx, claimIdempotencyKey, key, operation, requestHash, window.Seconds(), token, lease.Seconds()).Scan(new(string))
+	if err == nil {
+		return nil, token, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, "", fmt.Errorf("error claim idempotency key: %w", err)
+	}
+
+	storedHash := ""
+	var response []byte
+	if err = pg.pool.QueryRow(ctx, getIdempotencyKey, key, operation).Scan(&storedHash, &response); err != nil {
+		return nil, "", fmt.Errorf("error get idempotency key: %w", err)
+	}
+
+	if storedHash != requestHash {
+		return nil, "", ErrIdempotencyKeyMismatch
+	}
+
+	if response == nil {
+		return nil, "", ErrIdempotencyKeyInProgress
+	}
+
+	return response, "", nil
+}
+
+// сохраняет только запрос, который все еще держит ключ
+const saveIdempotencyResponse = `UPDATE idempotency_keys SET response = $4 WHERE key = $1 AND operation = $2 AND token = $3 AND response IS NULL`
+
+// SaveIdempotencyResponse сохраняет ответ операции, которая ничего не записала в своей транзакции,
+// например ответ с ошибками по всем парам. Если ответ уже сохранен операцией, ничего не делает
+func (pg *PostgresConn) SaveIdempotencyResponse(ctx context.Context, claim domain.IdempotencyClaim, response []byte) error {
+	if _, err := pg.pool.Exec(ctx, saveIdempotencyResponse, claim.Key, claim.Operation, claim.Token, response); err != nil {
+		return fmt.Errorf("error save idempotency response: %w", err)
+	}
+	return nil
+}
+
+// saveIdempotencyResponse сохраняет ответ операции в ее транзакции, если запрос пришел с ключом идемпотентности.
+// Если аренда ключа истекла и его занял другой запрос, операция откатывается
+func (pg *PostgresConn) saveIdempotencyResponse(ctx context.Context, tx pgx.Tx, result any) error {
+	claim, ok := domain.IdempotencyClaimFromContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	var data []byte
+	var err error
+	if claim.Response != nil {
+		data, err = claim.Response(result)
+	} else {
+		data, err = json.Marshal(result)
+	}
+	if err != nil {
+		return fmt.Errorf("error encode idempotency response: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, saveIdempotencyResponse, claim.Key, claim.Operation, claim.Token, data)
+	if err != nil {
+		return fmt.Errorf("error save idempotency response: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrIdempotencyKeyInProgress
+	}
+	return nil
+}
+
+const releaseIdempotencyKey = `DELETE FROM idempotency_keys WHERE key = $1 AND operation = $2 AND token = $3 AND response IS NULL`
+
+// ReleaseIdempotencyKey освобождает занятый ключ, если операция завершилась ошибкой,
+// чтобы клиент мог повторить запрос с тем же ключом
+func (pg *PostgresConn) ReleaseIdempotencyKey(ctx context.Context, claim domain.IdempotencyClaim) error {
+	if _, err := pg.pool.Exec(ctx, releaseIdempotencyKey, claim.Key, claim.Operation, claim.Token); err != nil {
+		return fmt.Errorf("error release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// deleteExpiredIdempotencyKeys удаляет ответы старше окна и ключи, брошенные без ответа после истечения аренды
+const deleteExpiredIdempotencyKeys = `DELETE FROM idempotency_keys
+WHERE (created_at < now() - make_interval(secs => $1) AND response IS NOT NULL)
+OR (response IS NULL AND locked_until < now())`
+
+func (pg *PostgresConn) DeleteExpiredIdempotencyKeys(ctx context.Context, window time.Duration) (int, error) {
+	tag, err := pg.pool.Exec(ctx, deleteExpiredIdempotencyKeys, window.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("error delete expired idempotency keys: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"warehouse/internal/core/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const selectKitComponents = `SELECT component_id, quantity FROM kit_components WHERE kit_id = $1 ORDER BY component_id`
+
+func getKitComponents(ctx context.Context, q querier, kitID int) ([]domain.KitComponent, error) {
+	rows, err := q.Query(ctx, selectKitComponents, kitID)
+	if err != nil {
+		return nil, fmt.Errorf("error get components of kit %d: %w", kitID, err)
+	}
+	components, err := pgx.CollectRows(rows, pgx.RowToStructByPos[domain.KitComponent])
+	if err != nil {
+		return nil, fmt.Errorf("error collect components of kit %d: %w", kitID, err)
+	}
+	return components, nil
+}
+
+// GetKitComponents возвращает состав комплекта, для обычного товара - пустой список
+func (pg *PostgresConn) GetKitComponents(ctx context.Context, kitID int) ([]domain.KitComponent, error) {
+	return getKitComponents(ctx, pg.pool, kitID)
+}
+
+const (
+	lockGood             = `SELECT id FROM goods WHERE id = $1 FOR UPDATE`
+	checkGoodHasStock    = `SELECT EXISTS(SELECT 1 FROM goods_warehouse WHERE good_id = $1)`
+	checkGoodIsComponent = `SELECT EXISTS(SELECT 1 FROM kit_components WHERE component_id = $1)`
+	checkGoodIsKit       = `SELECT EXISTS(SELECT 1 FROM kit_components WHERE kit_id = $1)`
+	deleteKitComponents  = `DELETE FROM kit_components WHERE kit_id = $1`
+	createKitComponent   = `INSERT INTO kit_components(kit_id, component_id, quantity) VALUES ($1, $2, $3)`
+)
+
+func queryExists(ctx context.Context, tx pgx.Tx, query string, id int) (bool, error) {
+	var exists bool
+	if err := tx.QueryRow(ctx, query, id).Scan(&exists); err != nil {
+		return false, fmt.Errorf("error check good %d: %w", id, err)
+	}
+	return exists, nil
+}
+
+// SetKitComponents заменяет состав комплекта, пустой список делает товар обычным.
+// Комплект не может иметь своего остатка, вложенные комплекты не поддерживаются
+func (pg *PostgresConn) SetKitComponents(ctx context.Context, kitID int, components []domain.KitComponent) error {
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = tx.QueryRow(ctx, lockGood, kitID).Scan(new(int)); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrIsNotExist
+		}
+		return fmt.Errorf("error lock good with id = %d: %w", kitID, err)
+	}
+
+	if len(components) > 0 {
+		hasStock, err := queryExists(ctx, tx, checkGoodHasStock, kitID)
+		if err != nil {
+			return err
+		}
+		if hasStock {
+			return ErrKitHasStock
+		}
+		isComponent, err := queryExists(ctx, tx, checkGoodIsComponent, kitID)
+		if err != nil {
+			return err
+		}
+		if isComponent {
+			return ErrNestedKit
+		}
+	}
+
+	if _, err = tx.Exec(ctx, deleteKitComponents, kitID); err != nil {
+		return fmt.Errorf("error delete components of kit %d: %w", kitID, err)
+	}
+
+	for _, c := range components {
+		if err = tx.QueryRow(ctx, lockGood, c.GoodID).Scan(new(int)); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrIsNotExist
+			}
+			return fmt.Errorf("error lock good with id = %d: %w", c.GoodID, err)
+		}
+		isKit, err := queryExists(ctx, tx, checkGoodIsKit, c.GoodID)
+		if err != nil {
+			return err
+		}
+		if isKit {
+			return ErrNestedKit
+		}
+		if _, err = tx.Exec(ctx, createKitComponent, kitID, c.GoodID, c.Quantity); err != nil {
+			return fmt.Errorf("error create component %d of kit %d: %w", c.GoodID, kitID, err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// reserveKit резервирует компоненты комплекта на складе пары в той же транзакции,
+// поэтому комплект резервируется целиком или не резервируется вовсе.
+// Строки резервации пишутся по компонентам, отгрузка и снятие резерва работают с ними как с обычными товарами
+func (pg *PostgresConn) reserveKit(ctx context.Context, tx pgx.Tx, reservationID int, pair *domain.PairGoodWarehouse, components []domain.KitComponent) error {
+	componentPairs, err := kitComponentPairs(*pair, components)
+	if err != nil {
+		return err
+	}
+	for i := range componentPairs {
+		if err = pg.reserveGood(ctx, tx, reservationID, &componentPairs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// kitComponentPairs раскладывает пару комплекта на пары его компонентов на складе пары:
+// c.Quantity * pair.Quantity единиц каждого компонента с той же резервацией
+func kitComponentPairs(pair domain.PairGoodWarehouse, components []domain.KitComponent) ([]domain.PairGoodWarehouse, error) {
+	if len(pair.Serials) > 0 {
+		// серийные номера компонентов подбираются автоматически
+		return nil, ErrSerialIsNotAvailable
+	}
+	pairs := make([]domain.PairGoodWarehouse, 0, len(components))
+	for _, c := range components {
+		pairs = append(pairs, domain.PairGoodWarehouse{
+			GoodID:        c.GoodID,
+			WarehouseID:   pair.WarehouseID,
+			Quantity:      c.Quantity * pair.Quantity,
+			ReservationID: pair.ReservationID,
+		})
+	}
+	return pairs, nil
+}
+
+// pairLine - то, что резервируется в строке goods_warehouse: обычный товар пары или компонент комплекта.
+// pairIndex указывает на пару запроса, к которой относится строка
+type pairLine struct {
+	pairIndex int
+	pair      domain.PairGoodWarehouse
+}
+
+// expandKits раскладывает пары на строки goods_warehouse, комплект - на свои компоненты на складе пары.
+// Пары с уже выставленной Error пропускаются, комплекту с серийными номерами выставляется ошибка
+func expandKits(ctx context.Context, q querier, pairs []domain.PairGoodWarehouse) ([]pairLine, error) {
+	lines := make([]pairLine, 0, len(pairs))
+	for i := range pairs {
+		if pairs[i].Error != nil {
+			continue
+		}
+		components, err := getKitComponents(ctx, q, pairs[i].GoodID)
+		if err != nil {
+			return nil, err
+		}
+		if len(components) == 0 {
+			lines = append(lines, pairLine{pairIndex: i, pair: pairs[i]})
+			continue
+		}
+		componentPairs, err := kitComponentPairs(pairs[i], components)
+		if err != nil {
+			pairs[i].Error = err
+			continue
+		}
+		for _, p := range componentPairs {
+			lines = append(lines, pairLine{pairIndex: i, pair: p})
+		}
+	}
+	return lines, nil
+}
+
+const (
+	kitAvailability = `SELECT warehouse.id, warehouse.name, warehouse.is_available, MIN((goods_warehouse.count - goods_warehouse.reserved) / kit_components.quantity)
+FROM kit_components INNER JOIN goods_warehouse ON goods_warehouse.good_id = kit_components.component_id
+INNER JOIN warehouse ON goods_warehouse.warehouse_id = warehouse.id
+WHERE kit_components.kit_id = $1
+GROUP BY warehouse.id, warehouse.name, warehouse.is_available
+HAVING COUNT(*) = (SELECT COUNT(*) FROM kit_components WHERE kit_id = $1)
+ORDER BY warehouse.id`
+	kitFreeStock = `SELECT warehouse.id, MIN((goods_warehouse.count - goods_warehouse.reserved) / kit_components.quantity), warehouse.priority
+FROM kit_components INNER JOIN goods_warehouse ON goods_warehouse.good_id = kit_components.component_id
+INNER JOIN warehouse ON goods_warehouse.warehouse_id = warehouse.id
+WHERE kit_components.kit_id = $1 AND warehouse.is_available
+GROUP BY warehouse.id, warehouse.priority
+HAVING COUNT(*) = (SELECT COUNT(*) FROM kit_components WHERE kit_id = $1)
+AND MIN((goods_warehouse.count - goods_warehouse.reserved) / kit_components.quantity) > 0
+ORDER BY warehouse.id`
+)
+
+// getKitAvailability считает по каждому складу, где есть все компоненты, сколько комплектов из них собирается
+func (pg *PostgresConn) getKitAvailability(ctx context.Context, kitID int) ([]domain.KitAvailability, error) {
+	rows, err := pg.pool.Query(ctx, kitAvailability, kitID)
+	if err != nil {
+		return nil, fmt.Errorf("error get availability of kit %d: %w", kitID, err)
+	}
+	availability, err := pgx.CollectRows(rows, pgx.RowToStructByPos[domain.KitAvailability])
+	if err != nil {
+		return nil, fmt.Errorf("error collect availability of kit %d: %w", kitID, err)
+	}
+	return availability, nil
+}
+
+// getKitFreeStock - аналог GetFreeStock для комплекта: свободный остаток в комплектах на доступных складах
+func (pg *PostgresConn) getKitFreeStock(ctx context.Context, kitID int) ([]domain.WarehouseStock, error) {
+	rows, err := pg.pool.Query(ctx, kitFreeStock, kitID)
+	if err != nil {
+		return nil, fmt.Errorf("error get free stock of kit %d: %w", kitID, err)
+	}
+	stocks, err := pgx.CollectRows(rows, pgx.RowToStructByPos[domain.WarehouseStock])
+	if err != nil {
+		return nil, fmt.Errorf("error collect free stock of kit %d: %w", kitID, err)
+	}
+	return stocks, nil
+}
+
+const lockGoodIsKit = `SELECT EXISTS(SELECT 1 FROM kit_components WHERE kit_id = goods.id) FROM goods WHERE id = $1 FOR SHARE`
+
+// checkNotKits запрещает приход на комплекты: их остаток складывается из остатков компонентов.
+// Строка товара блокируется, чтобы SetKitComponents не сделал его комплектом до конца транзакции
+func (pg *PostgresConn) checkNotKits(ctx context.Context, tx pgx.Tx, incoming []domain.GoodQuantity) error {
+	for _, in := range incoming {
+		var isKit bool
+		if err := tx.QueryRow(ctx, lockGoodIsKit, in.GoodID).Scan(&isKit); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrIsNotExist
+			}
+			return fmt.Errorf("error check good %d is kit: %w", in.GoodID, err)
+		}
+		if isKit {
+			return ErrGoodIsKit
+		}
+	}
+	return nil
+}
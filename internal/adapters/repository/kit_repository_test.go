@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"warehouse/internal/core/domain"
+)
+
+func TestKitComponentPairs(t *testing.T) {
+	components := []domain.KitComponent{
+		{GoodID: 1, Quantity: 1},
+		{GoodID: 2, Quantity: 3},
+	}
+
+	tests := []struct {
+		name       string
+		pair       domain.PairGoodWarehouse
+		components []domain.KitComponent
+		want       []domain.PairGoodWarehouse
+		wantErr    error
+	}{
+		{
+			name:       "components are multiplied by the kit quantity",
+			pair:       domain.PairGoodWarehouse{GoodID: 10, WarehouseID: 2, Quantity: 2},
+			components: components,
+			want: []domain.PairGoodWarehouse{
+				{GoodID: 1, WarehouseID: 2, Quantity: 2},
+				{GoodID: 2, WarehouseID: 2, Quantity: 6},
+			},
+		},
+		{
+			name:       "release keeps the reservation of the kit pair",
+			pair:       domain.PairGoodWarehouse{GoodID: 10, WarehouseID: 1, Quantity: 1, ReservationID: 7},
+			components: components,
+			want: []domain.PairGoodWarehouse{
+				{GoodID: 1, WarehouseID: 1, Quantity: 1, ReservationID: 7},
+				{GoodID: 2, WarehouseID: 1, Quantity: 3, ReservationID: 7},
+			},
+		},
+		{
+			name:       "serials of a kit are rejected",
+			pair:       domain.PairGoodWarehouse{GoodID: 10, WarehouseID: 1, Quantity: 1, Serials: []string{"SN-1"}},
+			components: components,
+			wantErr:    ErrSerialIsNotAvailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := kitComponentPairs(tt.pair, tt.components)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pairs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"warehouse/internal/core/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const addLot = `INSERT INTO lots(good_id, warehouse_id, number, expires_at, quantity) VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (good_id, warehouse_id, number) DO UPDATE SET quantity = lots.quantity + EXCLUDED.quantity
+WHERE lots.expires_at = EXCLUDED.expires_at`
+
+// addLot добавляет принятые единицы в партию. Срок годности существующей партии должен совпадать
+func (pg *PostgresConn) addLot(ctx context.Context, tx pgx.Tx, goodID, warehouseID int, lot domain.LotRef, quantity int) error {
+	tag, err := tx.Exec(ctx, addLot, goodID, warehouseID, lot.Number, lot.ExpiresAt, quantity)
+	if err != nil {
+		return fmt.Errorf("error add lot %s of good %d: %w", lot.Number, goodID, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrLotExpiryMismatch
+	}
+	return nil
+}
+
+const lockLots = `SELECT id, good_id, warehouse_id, number, expires_at, quantity, reserved FROM lots
+WHERE good_id = $1 AND warehouse_id = $2 AND quantity > 0 ORDER BY expires_at, id FOR UPDATE`
+
+// lockLots блокирует партии строки goods_warehouse в порядке FEFO
+func lockLotsFEFO(ctx context.Context, tx pgx.Tx, goodID, warehouseID int) ([]domain.Lot, error) {
+	rows, err := tx.Query(ctx, lockLots, goodID, warehouseID)
+	if err != nil {
+		return nil, fmt.Errorf("error lock lots of good %d in warehouse %d: %w", goodID, warehouseID, err)
+	}
+	lots, err := pgx.CollectRows(rows, pgx.RowToStructByPos[domain.Lot])
+	if err != nil {
+		return nil, fmt.Errorf("error collect lots of good %d in warehouse %d: %w", goodID, warehouseID, err)
+	}
+	return lots, nil
+}
+
+type lotAllocation struct {
+	lotID    int
+	quantity int
+}
+
+const (
+	reserveLot           = `UPDATE lots SET reserved = reserved + $2 WHERE id = $1`
+	createReservationLot = `INSERT INTO reservation_lots(reservation_id, lot_id, quantity) VALUES ($1, $2, $3)
+ON CONFLICT (reservation_id, lot_id) DO UPDATE SET quantity = reservation_lots.quantity + EXCLUDED.quantity`
+	releaseReservationLot = `UPDATE lots SET reserved = GREATEST(lots.reserved - rl.quantity, 0) FROM reservation_lots rl
+WHERE rl.reservation_id = $1 AND rl.lot_id = lots.id`
+	deleteReservationLots = `DELETE FROM reservation_lots WHERE reservation_id = $1`
+	consumeReservationLot = `UPDATE lots SET quantity = lots.quantity - rl.quantity, reserved = lots.reserved - rl.quantity FROM reservation_lots rl
+WHERE rl.reservation_id = $1 AND rl.lot_id = lots.id`
+)
+
+// reserveLots распределяет резерв по непросроченным партиям FEFO, остаток берется из единиц вне партий.
+// Вызывается до изменения reserved в goods_warehouse, ничего не пишет, если единиц не хватает
+func (pg *PostgresConn) reserveLots(ctx context.Context, tx pgx.Tx, reservationID int, gw goodInWarehouse, quantity int) error {
+	lots, err := lockLotsFEFO(ctx, tx, gw.GoodID, gw.WarehouseID)
+	if err != nil {
+		return err
+	}
+	if len(lots) == 0 {
+		return nil
+	}
+
+	untrackedFree := gw.Count - gw.Reserved
+	today := time.Now().Truncate(24 * time.Hour)
+	allocations := make([]lotAllocation, 0)
+	rest := quantity
+	for _, l := range lots {
+		untrackedFree -= l.Quantity - l.Reserved
+		if rest == 0 || l.ExpiresAt.Before(today) {
+			continue
+		}
+		q := min(rest, l.Quantity-l.Reserved)
+		if q > 0 {
+			allocations = append(allocations, lotAllocation{lotID: l.ID, quantity: q})
+			rest -= q
+		}
+	}
+	if rest > untrackedFree {
+		// свободный остаток есть только в просроченных партиях
+		return ErrNotEnoughGoods
+	}
+
+	for _, a := range allocations {
+		if _, err = tx.Exec(ctx, reserveLot, a.lotID, a.quantity); err != nil {
+			return fmt.Errorf("error reserve lot %d: %w", a.lotID, err)
+		}
+		if _, err = tx.Exec(ctx, createReservationLot, reservationID, a.lotID, a.quantity); err != nil {
+			return fmt.Errorf("error create reservation lot: %w", err)
+		}
+	}
+	return nil
+}
+
+// releaseReservedLots снимает резерв партий, закрепленных за резервацией
+func (pg *PostgresConn) releaseReservedLots(ctx context.Context, tx pgx.Tx, reservationID int) error {
+	if _, err := tx.Exec(ctx, releaseReservationLot, reservationID); err != nil {
+		return fmt.Errorf("error release lots of reservation with id = %d: %w", reservationID, err)
+	}
+	if _, err := tx.Exec(ctx, deleteReservationLots, reservationID); err != nil {
+		return fmt.Errorf("error delete lots of reservation with id = %d: %w", reservationID, err)
+	}
+	return nil
+}
+
+const (
+	lockReservationPairLots = `SELECT lots.id, reservation_lots.quantity FROM reservation_lots INNER JOIN lots ON reservation_lots.lot_id = lots.id
+WHERE reservation_lots.reservation_id = $1 AND lots.good_id = $2 AND lots.warehouse_id = $3
+ORDER BY lots.expires_at DESC, lots.id DESC FOR UPDATE OF reservation_lots, lots`
+	releaseLot                = `UPDATE lots SET reserved = reserved - $2 WHERE id = $1`
+	shrinkReservationLot      = `UPDATE reservation_lots SET quantity = quantity - $3 WHERE reservation_id = $1 AND lot_id = $2 AND quantity > $3`
+	deleteReservationLotByLot = `DELETE FROM reservation_lots WHERE reservation_id = $1 AND lot_id = $2 AND quantity = $3`
+)
+
+// releasePairLots снимает с партий резерв quantity единиц пары при частичном снятии резервации.
+// reservedTotal - сколько единиц пары всего в строках резервации. Сначала возвращаются единицы вне партий,
+// затем партии с самым поздним сроком, чтобы у резервации остались партии, которые истекают раньше
+func (pg *PostgresConn) releasePairLots(ctx context.Context, tx pgx.Tx, reservationID, goodID, warehouseID, reservedTotal, quantity int) error {
+	rows, err := tx.Query(ctx, lockReservationPairLots, reservationID, goodID, warehouseID)
+	if err != nil {
+		return fmt.Errorf("error lock lots of reservation with id = %d: %w", reservationID, err)
+	}
+	allocations, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (lotAllocation, error) {
+		a := lotAllocation{}
+		err := row.Scan(&a.lotID, &a.quantity)
+		return a, err
+	})
+	if err != nil {
+		return fmt.Errorf("error collect lots of reservation with id = %d: %w", reservationID, err)
+	}
+
+	untracked := reservedTotal
+	for _, a := range allocations {
+		untracked -= a.quantity
+	}
+	rest := quantity - min(untracked, quantity)
+	for _, a := range allocations {
+		if rest == 0 {
+			break
+		}
+		q := min(rest, a.quantity)
+		if _, err = tx.Exec(ctx, releaseLot, a.lotID, q); err != nil {
+			return fmt.Errorf("error release lot %d: %w", a.lotID, err)
+		}
+		tag, err := tx.Exec(ctx, deleteReservationLotByLot, reservationID, a.lotID, q)
+		if err != nil {
+			return fmt.Errorf("error release lot %d of reservation with id = %d: %w", a.lotID, reservationID, err)
+		}
+		if tag.RowsAffected() == 0 {
+			if _, err = tx.Exec(ctx, shrinkReservationLot, reservationID, a.lotID, q); err != nil {
+				return fmt.Errorf("error release lot %d of reservation with id = %d: %w", a.lotID, reservationID, err)
+			}
+		}
+		rest -= q
+	}
+	return nil
+}
+
+// consumeReservedLots списывает при отгрузке партии, закрепленные за резервацией.
+// Строки reservation_lots остаются, чтобы по отгрузке было видно, из каких партий она собрана
+func (pg *PostgresConn) consumeReservedLots(ctx context.Context, tx pgx.Tx, reservationID int) error {
+	if _, err := tx.Exec(ctx, consumeReservationLot, reservationID); err != nil {
+		return fmt.Errorf("error consume lots of reservation with id = %d: %w", reservationID, err)
+	}
+	return nil
+}
+
+const (
+	getLotsTotal = `SELECT COALESCE(SUM(quantity), 0) FROM lots WHERE good_id = $1 AND warehouse_id = $2`
+	getCount     = `SELECT count FROM goods_warehouse WHERE good_id = $1 AND warehouse_id = $2`
+	consumeLot   = `UPDATE lots SET quantity = quantity - $2 WHERE id = $1`
+)
+
+// movedLot - сколько единиц партии ушло со склада вместе с товаром
+type movedLot struct {
+	Number    string
+	ExpiresAt time.Time
+	Quantity  int
+}
+
+// lotsOverCount возвращает, на сколько единиц в партиях больше, чем останется в count после уменьшения на decrement
+func lotsOverCount(ctx context.Context, tx pgx.Tx, goodID, warehouseID, decrement int) (int, error) {
+	var total, count int
+	if err := tx.QueryRow(ctx, getLotsTotal, goodID, warehouseID).Scan(&total); err != nil {
+		return 0, fmt.Errorf("error get lots total of good %d in warehouse %d: %w", goodID, warehouseID, err)
+	}
+	if err := tx.QueryRow(ctx, getCount, goodID, warehouseID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("error get count of good %d in warehouse %d: %w", goodID, warehouseID, err)
+	}
+	return total - (count - decrement), nil
+}
+
+// consumeFreeLots списывает excess единиц со свободных партий FEFO и возвращает списанное
+func consumeFreeLots(ctx context.Context, tx pgx.Tx, goodID, warehouseID, excess int) ([]movedLot, error) {
+	if excess <= 0 {
+		return nil, nil
+	}
+
+	lots, err := lockLotsFEFO(ctx, tx, goodID, warehouseID)
+	if err != nil {
+		return nil, err
+	}
+	moved := make([]movedLot, 0)
+	for _, l := range lots {
+		if excess == 0 {
+			break
+		}
+		q := min(excess, l.Quantity-l.Reserved)
+		if q <= 0 {
+			continue
+		}
+		if _, err = tx.Exec(ctx, consumeLot, l.ID, q); err != nil {
+			return nil, fmt.Errorf("error consume lot %d: %w", l.ID, err)
+		}
+		moved = append(moved, movedLot{Number: l.Number, ExpiresAt: l.ExpiresAt, Quantity: q})
+		excess -= q
+	}
+	return moved, nil
+}
+
+// syncLots вызывается после уменьшения count без отгрузки резерва (списание, смена статуса).
+// Если в партиях оказалось больше единиц, чем в count, излишек списывается со свободных партий FEFO
+func (pg *PostgresConn) syncLots(ctx context.Context, tx pgx.Tx, goodID, warehouseID int) error {
+	excess, err := lotsOverCount(ctx, tx, goodID, warehouseID, 0)
+	if err != nil {
+		return err
+	}
+	_, err = consumeFreeLots(ctx, tx, goodID, warehouseID, excess)
+	return err
+}
+
+// takeLots списывает партии под quantity единиц, которые уходят со склада, чтобы партии пришли
+// на другой склад вместе с товаром. Вызывается до уменьшения count, выбирает единицы так же, как syncLots:
+// сначала единицы вне партий, затем свободные партии FEFO. После нее syncLots уже нечего списывать
+func (pg *PostgresConn) takeLots(ctx context.Context, tx pgx.Tx, goodID, warehouseID, quantity int) ([]movedLot, error) {
+	excess, err := lotsOverCount(ctx, tx, goodID, warehouseID, quantity)
+	if err != nil {
+		return nil, err
+	}
+	return consumeFreeLots(ctx, tx, goodID, warehouseID, excess)
+}
+
+const getExpiringLots = `SELECT id, good_id, warehouse_id, number, expires_at, quantity, reserved FROM lots
+WHERE quantity > 0 AND expires_at <= $1`
+
+// GetExpiringLots возвращает непустые партии со сроком годности до before включительно, уже просроченные тоже
+func (pg *PostgresConn) GetExpiringLots(ctx context.Context, warehouseID int, before time.Time) ([]domain.Lot, error) {
+	query := getExpiringLots
+	args := []any{before}
+	if warehouseID > 0 {
+		args = append(args, warehouseID)
+		query += " AND warehouse_id = $2"
+	}
+	query += " ORDER BY expires_at, id"
+
+	rows, err := pg.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error get expiring lots: %w", err)
+	}
+	lots, err := pgx.CollectRows(rows, pgx.RowToStructByPos[domain.Lot])
+	if err != nil {
+		return nil, fmt.Errorf("error collect expiring lots: %w", err)
+	}
+	return lots, nil
+}
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE reservations(
+    id SERIAL PRIMARY KEY,
+    order_ref VARCHAR(255) NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'released', 'fulfilled', 'expired')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE reservation_lines(
+    id SERIAL PRIMARY KEY,
+    reservation_id INTEGER NOT NULL REFERENCES reservations(id) ON DELETE CASCADE,
+    good_id INTEGER NOT NULL REFERENCES goods(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    warehouse_id INTEGER NOT NULL REFERENCES warehouse(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0)
+);
+
+CREATE INDEX reservations_order_ref_idx ON reservations(order_ref);
+CREATE INDEX reservation_lines_reservation_id_idx ON reservation_lines(reservation_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE reservation_lines;
+DROP TABLE reservations;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE reservations ADD COLUMN expires_at TIMESTAMPTZ;
+
+CREATE INDEX reservations_active_expires_at_idx ON reservations(expires_at) WHERE status = 'active';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX reservations_active_expires_at_idx;
+ALTER TABLE reservations DROP COLUMN expires_at;
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE goods_warehouse DROP CONSTRAINT goods_warehouse_count_check;
+ALTER TABLE goods_warehouse ADD CONSTRAINT goods_warehouse_count_check CHECK (count >= 0);
+ALTER TABLE goods_warehouse ADD CONSTRAINT goods_warehouse_reserved_check CHECK (reserved >= 0 AND reserved <= count);
+
+CREATE TABLE shipments(
+    id SERIAL PRIMARY KEY,
+    reservation_id INTEGER NOT NULL REFERENCES reservations(id) ON DELETE RESTRICT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE shipment_lines(
+    id SERIAL PRIMARY KEY,
+    shipment_id INTEGER NOT NULL REFERENCES shipments(id) ON DELETE CASCADE,
+    good_id INTEGER NOT NULL REFERENCES goods(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    warehouse_id INTEGER NOT NULL REFERENCES warehouse(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0)
+);
+
+CREATE INDEX shipments_reservation_id_idx ON shipments(reservation_id);
+CREATE INDEX shipment_lines_shipment_id_idx ON shipment_lines(shipment_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE shipment_lines;
+DROP TABLE shipments;
+ALTER TABLE goods_warehouse DROP CONSTRAINT goods_warehouse_reserved_check;
+ALTER TABLE goods_warehouse DROP CONSTRAINT goods_warehouse_count_check;
+ALTER TABLE goods_warehouse ADD CONSTRAINT goods_warehouse_count_check CHECK (count > 0);
+-- +goose StatementEnd
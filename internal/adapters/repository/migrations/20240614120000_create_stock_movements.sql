@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE stock_movements(
+    id BIGSERIAL PRIMARY KEY,
+    good_id INTEGER NOT NULL REFERENCES goods(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    warehouse_id INTEGER NOT NULL REFERENCES warehouse(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    type VARCHAR(16) NOT NULL CHECK (type IN ('receipt', 'reserve', 'release', 'fulfil', 'adjust', 'transfer')),
+    count_delta INTEGER NOT NULL,
+    reserved_delta INTEGER NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    reference VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX stock_movements_good_warehouse_idx ON stock_movements(good_id, warehouse_id, created_at);
+CREATE INDEX stock_movements_created_at_idx ON stock_movements(created_at);
+
+-- журнал только дописывается
+CREATE FUNCTION stock_movements_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'stock_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER stock_movements_append_only BEFORE UPDATE OR DELETE ON stock_movements
+    FOR EACH ROW EXECUTE FUNCTION stock_movements_append_only();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE stock_movements;
+DROP FUNCTION stock_movements_append_only();
+-- +goose StatementEnd
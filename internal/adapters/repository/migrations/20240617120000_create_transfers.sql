@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE transfers(
+    id SERIAL PRIMARY KEY,
+    good_id INTEGER NOT NULL REFERENCES goods(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    from_warehouse_id INTEGER NOT NULL REFERENCES warehouse(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    to_warehouse_id INTEGER NOT NULL REFERENCES warehouse(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    status VARCHAR(16) NOT NULL CHECK (status IN ('in_transit', 'received')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    received_at TIMESTAMPTZ,
+    CHECK (from_warehouse_id <> to_warehouse_id)
+);
+
+CREATE INDEX transfers_in_transit_idx ON transfers(to_warehouse_id) WHERE status = 'in_transit';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE transfers;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE count_sessions(
+    id SERIAL PRIMARY KEY,
+    warehouse_id INTEGER NOT NULL REFERENCES warehouse(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    status VARCHAR(16) NOT NULL CHECK (status IN ('open', 'approved', 'cancelled')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    closed_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX count_sessions_open_idx ON count_sessions(warehouse_id) WHERE status = 'open';
+
+CREATE TABLE count_session_lines(
+    session_id INTEGER NOT NULL REFERENCES count_sessions(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    good_id INTEGER NOT NULL REFERENCES goods(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    expected INTEGER NOT NULL CHECK (expected >= 0),
+    counted INTEGER CHECK (counted >= 0),
+    PRIMARY KEY (session_id, good_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE count_session_lines;
+DROP TABLE count_sessions;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE goods_warehouse
+    ADD COLUMN reorder_point INTEGER CHECK (reorder_point >= 0),
+    ADD COLUMN target_level INTEGER,
+    ADD CONSTRAINT goods_warehouse_target_level_check CHECK (target_level >= reorder_point);
+
+CREATE TABLE stock_alerts(
+    id SERIAL PRIMARY KEY,
+    good_id INTEGER NOT NULL REFERENCES goods(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    warehouse_id INTEGER NOT NULL REFERENCES warehouse(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    free INTEGER NOT NULL,
+    reorder_point INTEGER NOT NULL,
+    target_level INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    resolved_at TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX stock_alerts_open_idx ON stock_alerts(good_id, warehouse_id) WHERE resolved_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE stock_alerts;
+
+ALTER TABLE goods_warehouse
+    DROP CONSTRAINT goods_warehouse_target_level_check,
+    DROP COLUMN target_level,
+    DROP COLUMN reorder_point;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE receipts(
+    id SERIAL PRIMARY KEY,
+    warehouse_id INTEGER NOT NULL REFERENCES warehouse(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    supplier_ref VARCHAR(255) NOT NULL,
+    status VARCHAR(16) NOT NULL CHECK (status IN ('open', 'closed')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    closed_at TIMESTAMPTZ
+);
+
+CREATE TABLE receipt_lines(
+    receipt_id INTEGER NOT NULL REFERENCES receipts(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    good_id INTEGER NOT NULL REFERENCES goods(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    expected INTEGER NOT NULL CHECK (expected >= 0),
+    received INTEGER NOT NULL DEFAULT 0 CHECK (received >= 0),
+    PRIMARY KEY (receipt_id, good_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE receipt_lines;
+DROP TABLE receipts;
+-- +goose StatementEnd
@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE orders(
+    id SERIAL PRIMARY KEY,
+    reservation_id INTEGER NOT NULL UNIQUE REFERENCES reservations(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    status VARCHAR(16) NOT NULL CHECK (status IN ('picking', 'shipped')),
+    shipment_id INTEGER REFERENCES shipments(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    shipped_at TIMESTAMPTZ
+);
+
+CREATE TABLE pick_lists(
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    warehouse_id INTEGER NOT NULL REFERENCES warehouse(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    status VARCHAR(16) NOT NULL CHECK (status IN ('open', 'picked')),
+    UNIQUE (order_id, warehouse_id)
+);
+
+CREATE TABLE pick_list_lines(
+    pick_list_id INTEGER NOT NULL REFERENCES pick_lists(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    good_id INTEGER NOT NULL REFERENCES goods(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    picked INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (pick_list_id, good_id),
+    CHECK (picked >= 0 AND picked <= quantity)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE pick_list_lines;
+DROP TABLE pick_lists;
+DROP TABLE orders;
+-- +goose StatementEnd
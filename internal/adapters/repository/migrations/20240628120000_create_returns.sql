@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE stock_movements DROP CONSTRAINT stock_movements_type_check;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_type_check
+    CHECK (type IN ('receipt', 'reserve', 'release', 'fulfil', 'adjust', 'transfer', 'return'));
+
+CREATE TABLE returns(
+    id SERIAL PRIMARY KEY,
+    shipment_id INTEGER NOT NULL REFERENCES shipments(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    warehouse_id INTEGER NOT NULL REFERENCES warehouse(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX returns_shipment_idx ON returns(shipment_id);
+
+CREATE TABLE return_lines(
+    return_id INTEGER NOT NULL REFERENCES returns(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    good_id INTEGER NOT NULL REFERENCES goods(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    disposition VARCHAR(16) NOT NULL CHECK (disposition IN ('restock', 'quarantine', 'scrap')),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (return_id, good_id, disposition)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE return_lines;
+DROP TABLE returns;
+
+ALTER TABLE stock_movements DROP CONSTRAINT stock_movements_type_check;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_type_check
+    CHECK (type IN ('receipt', 'reserve', 'release', 'fulfil', 'adjust', 'transfer')) NOT VALID;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- count остается продаваемым остатком, резервировать можно только его
+ALTER TABLE goods_warehouse
+    ADD COLUMN quarantined INTEGER NOT NULL DEFAULT 0 CHECK (quarantined >= 0),
+    ADD COLUMN damaged INTEGER NOT NULL DEFAULT 0 CHECK (damaged >= 0);
+
+ALTER TABLE stock_movements
+    ADD COLUMN quarantined_delta INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN damaged_delta INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE stock_movements DROP CONSTRAINT stock_movements_type_check;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_type_check
+    CHECK (type IN ('receipt', 'reserve', 'release', 'fulfil', 'adjust', 'transfer', 'return', 'status'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE stock_movements DROP CONSTRAINT stock_movements_type_check;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_type_check
+    CHECK (type IN ('receipt', 'reserve', 'release', 'fulfil', 'adjust', 'transfer', 'return')) NOT VALID;
+
+ALTER TABLE stock_movements
+    DROP COLUMN damaged_delta,
+    DROP COLUMN quarantined_delta;
+
+ALTER TABLE goods_warehouse
+    DROP COLUMN damaged,
+    DROP COLUMN quarantined;
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+-- партии - часть продаваемого остатка goods_warehouse.count, единицы вне партий не отслеживаются
+CREATE TABLE lots(
+    id SERIAL PRIMARY KEY,
+    good_id INTEGER NOT NULL REFERENCES goods(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    warehouse_id INTEGER NOT NULL REFERENCES warehouse(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    number VARCHAR(255) NOT NULL,
+    expires_at DATE NOT NULL,
+    quantity INTEGER NOT NULL DEFAULT 0,
+    reserved INTEGER NOT NULL DEFAULT 0,
+    UNIQUE (good_id, warehouse_id, number),
+    CHECK (reserved >= 0 AND reserved <= quantity)
+);
+
+CREATE INDEX lots_expires_at_idx ON lots(expires_at) WHERE quantity > 0;
+
+CREATE TABLE reservation_lots(
+    reservation_id INTEGER NOT NULL REFERENCES reservations(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    lot_id INTEGER NOT NULL REFERENCES lots(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (reservation_id, lot_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE reservation_lots;
+DROP TABLE lots;
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE goods ADD COLUMN serial_tracked BOOLEAN NOT NULL DEFAULT false;
+
+-- серийные номера уникальны глобально, warehouse_id пустой у отгруженных
+CREATE TABLE serials(
+    id SERIAL PRIMARY KEY,
+    good_id INTEGER NOT NULL REFERENCES goods(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    number VARCHAR(255) NOT NULL UNIQUE,
+    warehouse_id INTEGER REFERENCES warehouse(id) ON DELETE SET NULL ON UPDATE CASCADE,
+    status VARCHAR(16) NOT NULL CHECK (status IN ('in_stock', 'reserved', 'shipped')),
+    reservation_id INTEGER REFERENCES reservations(id) ON DELETE SET NULL ON UPDATE CASCADE,
+    shipment_id INTEGER REFERENCES shipments(id) ON DELETE SET NULL ON UPDATE CASCADE
+);
+
+CREATE INDEX serials_good_warehouse_idx ON serials(good_id, warehouse_id) WHERE status = 'in_stock';
+CREATE INDEX serials_reservation_id_idx ON serials(reservation_id);
+
+CREATE TABLE serial_events(
+    id BIGSERIAL PRIMARY KEY,
+    serial_id INTEGER NOT NULL REFERENCES serials(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    type VARCHAR(16) NOT NULL CHECK (type IN ('receipt', 'reserve', 'release', 'ship')),
+    warehouse_id INTEGER REFERENCES warehouse(id) ON DELETE SET NULL ON UPDATE CASCADE,
+    reference VARCHAR(255) NOT NULL DEFAULT '',
+    actor VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX serial_events_serial_id_idx ON serial_events(serial_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE serial_events;
+DROP TABLE serials;
+ALTER TABLE goods DROP COLUMN serial_tracked;
+-- +goose StatementEnd
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE zones(
+    id SERIAL PRIMARY KEY,
+    warehouse_id INTEGER NOT NULL REFERENCES warehouse(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    code VARCHAR(64) NOT NULL,
+    UNIQUE (warehouse_id, code)
+);
+
+CREATE TABLE aisles(
+    id SERIAL PRIMARY KEY,
+    zone_id INTEGER NOT NULL REFERENCES zones(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    code VARCHAR(64) NOT NULL,
+    UNIQUE (zone_id, code)
+);
+
+CREATE TABLE bins(
+    id SERIAL PRIMARY KEY,
+    aisle_id INTEGER NOT NULL REFERENCES aisles(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    code VARCHAR(64) NOT NULL,
+    UNIQUE (aisle_id, code)
+);
+
+-- содержимое ячеек - часть физического остатка goods_warehouse (count + quarantined + damaged),
+-- единицы, еще не разложенные по ячейкам, считаются неразмещенными
+CREATE TABLE bin_stock(
+    bin_id INTEGER NOT NULL REFERENCES bins(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    good_id INTEGER NOT NULL REFERENCES goods(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (bin_id, good_id)
+);
+
+CREATE INDEX bin_stock_good_id_idx ON bin_stock(good_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE bin_stock;
+DROP TABLE bins;
+DROP TABLE aisles;
+DROP TABLE zones;
+-- +goose StatementEnd
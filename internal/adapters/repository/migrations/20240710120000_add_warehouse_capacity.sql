@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- объем склада в единицах goods.size, 0 - без ограничения
+ALTER TABLE warehouse ADD COLUMN capacity INTEGER NOT NULL DEFAULT 0 CHECK (capacity >= 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE warehouse DROP COLUMN capacity;
+-- +goose StatementEnd
@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+-- товар с компонентами - комплект, своего остатка у него нет, резервируются компоненты
+CREATE TABLE kit_components(
+    kit_id INTEGER NOT NULL REFERENCES goods(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    component_id INTEGER NOT NULL REFERENCES goods(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (kit_id, component_id),
+    CHECK (kit_id <> component_id)
+);
+
+CREATE INDEX kit_components_component_id_idx ON kit_components(component_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE kit_components;
+-- +goose StatementEnd
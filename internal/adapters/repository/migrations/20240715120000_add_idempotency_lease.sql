@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- token отличает запрос, занявший ключ, от следующего, занявшего его после истечения аренды.
+-- Ключ без ответа с истекшей арендой считается брошенным: его можно занять заново или удалить
+ALTER TABLE idempotency_keys ADD COLUMN token VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE idempotency_keys ADD COLUMN locked_until TIMESTAMPTZ NOT NULL DEFAULT now();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE idempotency_keys DROP COLUMN locked_until;
+ALTER TABLE idempotency_keys DROP COLUMN token;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- партии, ушедшие с исходного склада по переносу, приходят на склад назначения с тем же сроком годности
+CREATE TABLE transfer_lots(
+    transfer_id INTEGER NOT NULL REFERENCES transfers(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    number VARCHAR(255) NOT NULL,
+    expires_at DATE NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (transfer_id, number)
+);
+
+-- партии, в которые вернулись единицы возврата restock
+CREATE TABLE return_lots(
+    return_id INTEGER NOT NULL REFERENCES returns(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    good_id INTEGER NOT NULL REFERENCES goods(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    number VARCHAR(255) NOT NULL,
+    expires_at DATE NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (return_id, good_id, number, expires_at)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE return_lots;
+DROP TABLE transfer_lots;
+-- +goose StatementEnd
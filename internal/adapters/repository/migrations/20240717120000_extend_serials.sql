@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- номер следует за остатком: в пути между складами, в карантине, браке и после списания
+ALTER TABLE serials DROP CONSTRAINT serials_status_check;
+ALTER TABLE serials ADD CONSTRAINT serials_status_check
+    CHECK (status IN ('in_stock', 'reserved', 'shipped', 'in_transit', 'quarantined', 'damaged', 'written_off'));
+
+ALTER TABLE serial_events DROP CONSTRAINT serial_events_type_check;
+ALTER TABLE serial_events ADD CONSTRAINT serial_events_type_check
+    CHECK (type IN ('receipt', 'reserve', 'release', 'ship', 'transfer_out', 'transfer_in', 'return', 'adjust', 'status'));
+
+-- номера, ушедшие с исходного склада по переносу
+CREATE TABLE transfer_serials(
+    transfer_id INTEGER NOT NULL REFERENCES transfers(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    serial_id INTEGER NOT NULL REFERENCES serials(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    PRIMARY KEY (transfer_id, serial_id)
+);
+
+-- номера, найденные или не найденные при пересчете
+ALTER TABLE count_session_lines ADD COLUMN serials TEXT[];
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE count_session_lines DROP COLUMN serials;
+DROP TABLE transfer_serials;
+UPDATE serials SET status = 'shipped', warehouse_id = NULL WHERE status IN ('in_transit', 'written_off');
+UPDATE serials SET status = 'in_stock' WHERE status IN ('quarantined', 'damaged');
+DELETE FROM serial_events WHERE type IN ('transfer_out', 'transfer_in', 'return', 'adjust', 'status');
+ALTER TABLE serial_events DROP CONSTRAINT serial_events_type_check;
+ALTER TABLE serial_events ADD CONSTRAINT serial_events_type_check CHECK (type IN ('receipt', 'reserve', 'release', 'ship'));
+ALTER TABLE serials DROP CONSTRAINT serials_status_check;
+ALTER TABLE serials ADD CONSTRAINT serials_status_check CHECK (status IN ('in_stock', 'reserved', 'shipped'));
+-- +goose StatementEnd
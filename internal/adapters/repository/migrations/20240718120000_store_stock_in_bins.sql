@@ -0,0 +1,105 @@
+-- +goose Up
+-- +goose StatementBegin
+-- весь физический остаток лежит в ячейках: принятые, но еще не разложенные единицы - в ячейке приемки склада
+ALTER TABLE bins ADD COLUMN receiving BOOLEAN NOT NULL DEFAULT false;
+
+DO $$
+DECLARE
+    w RECORD;
+    zone INTEGER;
+    aisle INTEGER;
+    bin INTEGER;
+BEGIN
+    FOR w IN SELECT id FROM warehouse LOOP
+        INSERT INTO zones(warehouse_id, code) VALUES (w.id, 'RECEIVING')
+        ON CONFLICT (warehouse_id, code) DO UPDATE SET code = EXCLUDED.code RETURNING id INTO zone;
+        INSERT INTO aisles(zone_id, code) VALUES (zone, 'RECEIVING')
+        ON CONFLICT (zone_id, code) DO UPDATE SET code = EXCLUDED.code RETURNING id INTO aisle;
+        INSERT INTO bins(aisle_id, code, receiving) VALUES (aisle, 'RECEIVING', true)
+        ON CONFLICT (aisle_id, code) DO UPDATE SET receiving = true RETURNING id INTO bin;
+
+        INSERT INTO bin_stock(bin_id, good_id, quantity)
+        SELECT bin, gw.good_id, gw.count + gw.quarantined + gw.damaged - COALESCE((
+            SELECT SUM(bin_stock.quantity) FROM bin_stock
+            INNER JOIN bins ON bin_stock.bin_id = bins.id INNER JOIN aisles ON bins.aisle_id = aisles.id INNER JOIN zones ON aisles.zone_id = zones.id
+            WHERE bin_stock.good_id = gw.good_id AND zones.warehouse_id = w.id
+        ), 0) AS unbinned
+        FROM goods_warehouse gw WHERE gw.warehouse_id = w.id
+        AND gw.count + gw.quarantined + gw.damaged > COALESCE((
+            SELECT SUM(bin_stock.quantity) FROM bin_stock
+            INNER JOIN bins ON bin_stock.bin_id = bins.id INNER JOIN aisles ON bins.aisle_id = aisles.id INNER JOIN zones ON aisles.zone_id = zones.id
+            WHERE bin_stock.good_id = gw.good_id AND zones.warehouse_id = w.id
+        ), 0)
+        ON CONFLICT (bin_id, good_id) DO UPDATE SET quantity = bin_stock.quantity + EXCLUDED.quantity;
+    END LOOP;
+END;
+$$;
+
+-- физический остаток строки goods_warehouse (count + quarantined + damaged) - сумма ячеек склада.
+-- Проверка откладывается до конца транзакции, чтобы остаток и ячейки менялись по очереди
+CREATE FUNCTION check_bin_stock() RETURNS trigger AS $$
+DECLARE
+    good INTEGER;
+    wh INTEGER;
+    physical INTEGER;
+    binned INTEGER;
+BEGIN
+    IF TG_TABLE_NAME = 'goods_warehouse' THEN
+        good := NEW.good_id;
+        wh := NEW.warehouse_id;
+    ELSE
+        good := COALESCE(NEW.good_id, OLD.good_id);
+        SELECT zones.warehouse_id INTO wh FROM bins
+        INNER JOIN aisles ON bins.aisle_id = aisles.id INNER JOIN zones ON aisles.zone_id = zones.id
+        WHERE bins.id = COALESCE(NEW.bin_id, OLD.bin_id);
+        IF wh IS NULL THEN
+            RETURN NULL;
+        END IF;
+    END IF;
+
+    SELECT count + quarantined + damaged INTO physical FROM goods_warehouse WHERE good_id = good AND warehouse_id = wh;
+    SELECT COALESCE(SUM(bin_stock.quantity), 0) INTO binned FROM bin_stock
+    INNER JOIN bins ON bin_stock.bin_id = bins.id INNER JOIN aisles ON bins.aisle_id = aisles.id INNER JOIN zones ON aisles.zone_id = zones.id
+    WHERE bin_stock.good_id = good AND zones.warehouse_id = wh;
+
+    IF COALESCE(physical, 0) <> binned THEN
+        RAISE EXCEPTION 'stock of good % in warehouse % is %, bins hold %', good, wh, COALESCE(physical, 0), binned
+            USING ERRCODE = 'check_violation';
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER goods_warehouse_bin_stock AFTER INSERT OR UPDATE OF count, quarantined, damaged ON goods_warehouse
+    DEFERRABLE INITIALLY DEFERRED FOR EACH ROW EXECUTE FUNCTION check_bin_stock();
+CREATE CONSTRAINT TRIGGER bin_stock_goods_warehouse AFTER INSERT OR UPDATE OR DELETE ON bin_stock
+    DEFERRABLE INITIALLY DEFERRED FOR EACH ROW EXECUTE FUNCTION check_bin_stock();
+
+-- ячейка, из которой ушли или в которую пришли единицы, пусто - физический остаток не менялся
+ALTER TABLE stock_movements ADD COLUMN bin_id INTEGER REFERENCES bins(id) ON DELETE RESTRICT ON UPDATE CASCADE;
+
+-- ячейки, из которых собраны единицы листа сборки
+CREATE TABLE pick_list_bins(
+    pick_list_id INTEGER NOT NULL REFERENCES pick_lists(id) ON DELETE CASCADE ON UPDATE CASCADE,
+    good_id INTEGER NOT NULL REFERENCES goods(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    bin_id INTEGER NOT NULL REFERENCES bins(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (pick_list_id, good_id, bin_id)
+);
+
+-- ячейка, в которой найден излишек или пересчитана недостача
+ALTER TABLE count_session_lines ADD COLUMN bin_id INTEGER REFERENCES bins(id) ON DELETE SET NULL ON UPDATE CASCADE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE count_session_lines DROP COLUMN bin_id;
+DROP TABLE pick_list_bins;
+ALTER TABLE stock_movements DROP COLUMN bin_id;
+DROP TRIGGER bin_stock_goods_warehouse ON bin_stock;
+DROP TRIGGER goods_warehouse_bin_stock ON goods_warehouse;
+DROP FUNCTION check_bin_stock();
+DELETE FROM bin_stock WHERE bin_id IN (SELECT id FROM bins WHERE receiving);
+DELETE FROM bins WHERE receiving;
+ALTER TABLE bins DROP COLUMN receiving;
+-- +goose StatementEnd
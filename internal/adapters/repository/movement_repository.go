@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"warehouse/internal/core/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const changeStock = `INSERT INTO goods_warehouse(warehouse_id, good_id, count, reserved, quarantined, damaged) VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (warehouse_id, good_id) DO UPDATE SET count = goods_warehouse.count + EXCLUDED.count, reserved = goods_warehouse.reserved + EXCLUDED.reserved,
+quarantined = goods_warehouse.quarantined + EXCLUDED.quarantined, damaged = goods_warehouse.damaged + EXCLUDED.damaged`
+
+const createMovement = `INSERT INTO stock_movements(good_id, warehouse_id, type, count_delta, reserved_delta, quarantined_delta, damaged_delta, actor, reason, reference, bin_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, 0))`
+
+// changeStock - единственное место, где меняются остатки (count, reserved, quarantined, damaged) в goods_warehouse.
+// Вместе с изменением в той же транзакции пишется запись в журнал движений.
+// Физический остаток (count + quarantined + damaged) меняется вместе с ячейками движения, см. stockBins,
+// поэтому остаток склада всегда равен сумме его ячеек.
+// Строка goods_warehouse, которой еще нет, создается с переданными значениями
+func (pg *PostgresConn) changeStock(ctx context.Context, tx pgx.Tx, m domain.StockMovement) error {
+	if m.CountDelta == 0 && m.ReservedDelta == 0 && m.QuarantinedDelta == 0 && m.DamagedDelta == 0 {
+		return nil
+	}
+
+	physical := m.CountDelta + m.QuarantinedDelta + m.DamagedDelta
+	takes := make([]binTake, 0)
+	if physical != 0 {
+		var err error
+		if takes, err = stockBins(ctx, tx, m, physical); err != nil {
+			return err
+		}
+	}
+	for _, t := range takes {
+		if physical > 0 {
+			if _, err := tx.Exec(ctx, addBinStock, t.BinID, m.GoodID, t.Quantity); err != nil {
+				return fmt.Errorf("error put good %d into bin %d: %w", m.GoodID, t.BinID, err)
+			}
+		} else if err := takeFromBin(ctx, tx, t.BinID, m.GoodID, t.Quantity); err != nil {
+			return err
+		}
+	}
+	// расход без ячейки может пройти по нескольким ячейкам, тогда ячейка в журнале не пишется
+	m.BinID = 0
+	if len(takes) == 1 {
+		m.BinID = takes[0].BinID
+	}
+
+	if _, err := tx.Exec(ctx, changeStock, m.WarehouseID, m.GoodID, m.CountDelta, m.ReservedDelta, m.QuarantinedDelta, m.DamagedDelta); err != nil {
+		return fmt.Errorf("error change stock of good %d in warehouse %d: %w", m.GoodID, m.WarehouseID, err)
+	}
+
+	if _, err := tx.Exec(ctx, createMovement, m.GoodID, m.WarehouseID, m.Type, m.CountDelta, m.ReservedDelta,
+		m.QuarantinedDelta, m.DamagedDelta, domain.ActorFromContext(ctx), m.Reason, m.Reference, m.BinID); err != nil {
+		return fmt.Errorf("error write stock movement: %w", err)
+	}
+
+	if m.CountDelta < 0 && m.ReservedDelta == 0 {
+		return pg.syncLots(ctx, tx, m.GoodID, m.WarehouseID)
+	}
+	return nil
+}
+
+func reservationReference(id int) string {
+	return fmt.Sprintf("reservation:%d", id)
+}
+
+func shipmentReference(id int) string {
+	return fmt.Sprintf("shipment:%d", id)
+}
+
+func transferReference(id int) string {
+	return fmt.Sprintf("transfer:%d", id)
+}
+
+func receiptReference(id int) string {
+	return fmt.Sprintf("receipt:%d", id)
+}
+
+func returnReference(id int) string {
+	return fmt.Sprintf("return:%d", id)
+}
+
+func countSessionReference(id int) string {
+	return fmt.Sprintf("count_session:%d", id)
+}
+
+const getStockMovements = `SELECT id, good_id, warehouse_id, type, count_delta, reserved_delta, quarantined_delta, damaged_delta, actor, reason, reference, created_at,
+COALESCE(bin_id, 0) FROM stock_movements`
+
+func (pg *PostgresConn) GetStockMovements(ctx context.Context, filter domain.StockMovementFilter) ([]domain.StockMovement, error) {
+	where := make([]string, 0, 4)
+	args := make([]any, 0, 5)
+	if filter.GoodID > 0 {
+		args = append(args, filter.GoodID)
+		where = append(where, fmt.Sprintf("good_id = $%d", len(args)))
+	}
+	if filter.WarehouseID > 0 {
+		args = append(args, filter.WarehouseID)
+		where = append(where, fmt.Sprintf("warehouse_id = $%d", len(args)))
+	}
+	if !filter.From.IsZero() {
+		args = append(args, filter.From)
+		where = append(where, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if !filter.To.IsZero() {
+		args = append(args, filter.To)
+		where = append(where, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+
+	query := getStockMovements
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY created_at, id LIMIT $%d", len(args))
+
+	rows, err := pg.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error get stock movements: %w", err)
+	}
+
+	movements, err := pgx.CollectRows(rows, pgx.RowToStructByPos[domain.StockMovement])
+	if err != nil {
+		return nil, fmt.Errorf("error scan from rows: %w", err)
+	}
+
+	return movements, nil
+}
@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"warehouse/internal/core/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	checkReservationOrder = `SELECT EXISTS(SELECT 1 FROM orders WHERE reservation_id = $1)`
+	pinReservation        = `UPDATE reservations SET expires_at = NULL WHERE id = $1`
+	createOrder           = `INSERT INTO orders(reservation_id, status) VALUES ($1, 'picking') RETURNING id`
+	createPickList        = `INSERT INTO pick_lists(order_id, warehouse_id, status) VALUES ($1, $2, 'open') RETURNING id`
+	createPickListLine    = `INSERT INTO pick_list_lines(pick_list_id, good_id, quantity) VALUES ($1, $2, $3)`
+	// у резервации может быть несколько строк одного товара на складе (комплекты, повторные пары),
+	// а в листе сборки товар - одна строка
+	getReservationPickLines = `SELECT good_id, warehouse_id, SUM(quantity) FROM reservation_lines WHERE reservation_id = $1
+GROUP BY warehouse_id, good_id ORDER BY warehouse_id, good_id`
+)
+
+// CreateOrder создает заказ по активной резервации и листы сборки по складам из ее строк.
+// Срок жизни резервации снимается, чтобы она не истекла во время сборки
+func (pg *PostgresConn) CreateOrder(ctx context.Context, reservationID int) (domain.Order, error) {
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return domain.Order{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = pg.lockActiveReservation(ctx, tx, reservationID); err != nil {
+		return domain.Order{}, err
+	}
+
+	var isExist bool
+	if err = tx.QueryRow(ctx, checkReservationOrder, reservationID).Scan(&isExist); err != nil {
+		return domain.Order{}, fmt.Errorf("error check order of reservation with id = %d: %w", reservationID, err)
+	}
+	if isExist {
+		return domain.Order{}, ErrOrderIsExist
+	}
+
+	if _, err = tx.Exec(ctx, pinReservation, reservationID); err != nil {
+		return domain.Order{}, fmt.Errorf("error pin reservation with id = %d: %w", reservationID, err)
+	}
+
+	var orderID int
+	if err = tx.QueryRow(ctx, createOrder, reservationID).Scan(&orderID); err != nil {
+		return domain.Order{}, fmt.Errorf("error create order: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, getReservationPickLines, reservationID)
+	if err != nil {
+		return domain.Order{}, fmt.Errorf("error get lines of reservation with id = %d: %w", reservationID, err)
+	}
+	lines, err := pgx.CollectRows(rows, pgx.RowToStructByPos[domain.ReservationLine])
+	if err != nil {
+		return domain.Order{}, fmt.Errorf("error collect lines of reservation with id = %d: %w", reservationID, err)
+	}
+
+	// строки отсортированы по складу, поэтому лист сборки создается при смене склада
+	pickListID, warehouseID := 0, 0
+	for _, l := range lines {
+		if l.WarehouseID != warehouseID {
+			warehouseID = l.WarehouseID
+			if err = tx.QueryRow(ctx, createPickList, orderID, warehouseID).Scan(&pickListID); err != nil {
+				return domain.Order{}, fmt.Errorf("error create pick list: %w", err)
+			}
+		}
+		if _, err = tx.Exec(ctx, createPickListLine, pickListID, l.GoodID, l.Quantity); err != nil {
+			return domain.Order{}, fmt.Errorf("error create pick list line: %w", err)
+		}
+	}
+
+	o, err := selectOrder(ctx, tx, orderID)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return domain.Order{}, err
+	}
+	return o, nil
+}
+
+const (
+	getOrder          = `SELECT id, reservation_id, status, shipment_id, created_at, shipped_at FROM orders WHERE id = $1`
+	lockOrder         = getOrder + ` FOR UPDATE`
+	getPickLists      = `SELECT id, order_id, warehouse_id, status FROM pick_lists WHERE order_id = $1 ORDER BY warehouse_id`
+	getPickListLines  = `SELECT good_id, quantity, picked FROM pick_list_lines WHERE pick_list_id = $1 ORDER BY good_id`
+	getPickListOrder  = `SELECT order_id, warehouse_id FROM pick_lists WHERE id = $1`
+	pickLine          = `UPDATE pick_list_lines SET picked = picked + $3 WHERE pick_list_id = $1 AND good_id = $2 AND picked + $3 <= quantity`
+	checkPickLine     = `SELECT EXISTS(SELECT 1 FROM pick_list_lines WHERE pick_list_id = $1 AND good_id = $2)`
+	createPickListBin = `INSERT INTO pick_list_bins(pick_list_id, good_id, bin_id, quantity) VALUES ($1, $2, $3, $4)
+ON CONFLICT (pick_list_id, good_id, bin_id) DO UPDATE SET quantity = pick_list_bins.quantity + EXCLUDED.quantity`
+	getOrderPicks = `SELECT plb.good_id, plb.bin_id, plb.quantity FROM pick_list_bins plb
+INNER JOIN pick_lists ON plb.pick_list_id = pick_lists.id WHERE pick_lists.order_id = $1 ORDER BY plb.good_id, plb.bin_id`
+	setPickListPicked = `UPDATE pick_lists SET status = 'picked'
+WHERE id = $1 AND NOT EXISTS(SELECT 1 FROM pick_list_lines WHERE pick_list_id = $1 AND picked < quantity)`
+	setOrderShipped = `UPDATE orders SET status = 'shipped', shipment_id = $2, shipped_at = now() WHERE id = $1`
+)
+
+func scanOrder(row pgx.Row) (domain.Order, error) {
+	o := domain.Order{}
+	err := row.Scan(&o.ID, &o.ReservationID, &o.Status, &o.ShipmentID, &o.CreatedAt, &o.ShippedAt)
+	return o, err
+}
+
+func selectPickLists(ctx context.Context, q querier, orderID int) ([]domain.PickList, error) {
+	rows, err := q.Query(ctx, getPickLists, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("error get pick lists of order with id = %d: %w", orderID, err)
+	}
+
+	pickLists, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.PickList, error) {
+		pl := domain.PickList{}
+		err := row.Scan(&pl.ID, &pl.OrderID, &pl.WarehouseID, &pl.Status)
+		return pl, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error collect pick lists of order with id = %d: %w", orderID, err)
+	}
+
+	for i := range pickLists {
+		rows, err = q.Query(ctx, getPickListLines, pickLists[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("error get lines of pick list with id = %d: %w", pickLists[i].ID, err)
+		}
+		pickLists[i].Lines, err = pgx.CollectRows(rows, pgx.RowToStructByPos[domain.PickLine])
+		if err != nil {
+			return nil, fmt.Errorf("error collect lines of pick list with id = %d: %w", pickLists[i].ID, err)
+		}
+	}
+	return pickLists, nil
+}
+
+func selectOrder(ctx context.Context, q querier, id int) (domain.Order, error) {
+	o, err := scanOrder(q.QueryRow(ctx, getOrder, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Order{}, ErrNotFound
+		}
+		return domain.Order{}, fmt.Errorf("error get order with id = %d: %w", id, err)
+	}
+
+	if o.PickLists, err = selectPickLists(ctx, q, id); err != nil {
+		return domain.Order{}, err
+	}
+	return o, nil
+}
+
+func (pg *PostgresConn) GetOrder(ctx context.Context, id int) (domain.Order, error) {
+	return selectOrder(ctx, pg.pool, id)
+}
+
+// PickGoods отмечает собранное количество в листе сборки и ячейки, из которых взяты единицы.
+// Когда собраны все листы заказа, в той же транзакции резервация отгружается: count и reserved
+// уменьшаются, единицы снимаются с названных ячеек, заказ становится shipped
+func (pg *PostgresConn) PickGoods(ctx context.Context, pickListID int, picked []domain.Pick) (domain.Order, error) {
+	var orderID, warehouseID int
+	if err := pg.pool.QueryRow(ctx, getPickListOrder, pickListID).Scan(&orderID, &warehouseID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Order{}, ErrNotFound
+		}
+		return domain.Order{}, fmt.Errorf("error get pick list with id = %d: %w", pickListID, err)
+	}
+
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return domain.Order{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	o, err := scanOrder(tx.QueryRow(ctx, lockOrder, orderID))
+	if err != nil {
+		return domain.Order{}, fmt.Errorf("error lock order with id = %d: %w", orderID, err)
+	}
+	if o.Status != domain.OrderPicking {
+		return domain.Order{}, ErrOrderIsNotPicking
+	}
+
+	for _, p := range picked {
+		tag, err := tx.Exec(ctx, pickLine, pickListID, p.GoodID, p.Quantity)
+		if err != nil {
+			return domain.Order{}, fmt.Errorf("error pick good %d: %w", p.GoodID, err)
+		}
+		if tag.RowsAffected() == 1 {
+			if err = pickFromBin(ctx, tx, pickListID, warehouseID, p); err != nil {
+				return domain.Order{}, err
+			}
+			continue
+		}
+
+		var isExist bool
+		if err = tx.QueryRow(ctx, checkPickLine, pickListID, p.GoodID).Scan(&isExist); err != nil {
+			return domain.Order{}, fmt.Errorf("error check pick line of good %d: %w", p.GoodID, err)
+		}
+		if !isExist {
+			return domain.Order{}, ErrFailedCheckGoodInWarehouse
+		}
+		return domain.Order{}, ErrPickExceedsQuantity
+	}
+
+	if _, err = tx.Exec(ctx, setPickListPicked, pickListID); err != nil {
+		return domain.Order{}, fmt.Errorf("error set pick list with id = %d picked: %w", pickListID, err)
+	}
+
+	if o.PickLists, err = selectPickLists(ctx, tx, orderID); err != nil {
+		return domain.Order{}, err
+	}
+
+	isPicked := true
+	for _, pl := range o.PickLists {
+		isPicked = isPicked && pl.Status == domain.PickListPicked
+	}
+
+	if isPicked {
+		if err = pg.lockActiveReservation(ctx, tx, o.ReservationID); err != nil {
+			return domain.Order{}, err
+		}
+
+		rows, err := tx.Query(ctx, getOrderPicks, orderID)
+		if err != nil {
+			return domain.Order{}, fmt.Errorf("error get picks of order with id = %d: %w", orderID, err)
+		}
+		picks, err := pgx.CollectRows(rows, pgx.RowToStructByPos[domain.Pick])
+		if err != nil {
+			return domain.Order{}, fmt.Errorf("error collect picks of order with id = %d: %w", orderID, err)
+		}
+
+		sh, err := pg.fulfilReservation(ctx, tx, o.ReservationID, picks)
+		if err != nil {
+			return domain.Order{}, err
+		}
+
+		if _, err = tx.Exec(ctx, setOrderShipped, orderID, sh.ID); err != nil {
+			return domain.Order{}, fmt.Errorf("error set order with id = %d shipped: %w", orderID, err)
+		}
+
+		if o, err = selectOrder(ctx, tx, orderID); err != nil {
+			return domain.Order{}, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return domain.Order{}, err
+	}
+	return o, nil
+}
+
+// pickFromBin запоминает ячейку, из которой собраны единицы; она должна быть на складе листа сборки
+func pickFromBin(ctx context.Context, tx pgx.Tx, pickListID, warehouseID int, p domain.Pick) error {
+	if p.BinID == 0 {
+		return nil
+	}
+	binWarehouseID, err := binWarehouse(ctx, tx, p.BinID)
+	if err != nil {
+		return err
+	}
+	if binWarehouseID != warehouseID {
+		return ErrBinIsNotInWarehouse
+	}
+	if _, err = tx.Exec(ctx, createPickListBin, pickListID, p.GoodID, p.BinID, p.Quantity); err != nil {
+		return fmt.Errorf("error pick good %d from bin %d: %w", p.GoodID, p.BinID, err)
+	}
+	return nil
+}
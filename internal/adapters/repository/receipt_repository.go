@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"warehouse/internal/core/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func (pg *PostgresConn) goodsIsExist(ctx context.Context, lines []domain.GoodQuantity) (bool, error) {
+	for _, l := range lines {
+		isExist, err := pg.goodIsExist(ctx, l.GoodID)
+		if err != nil || !isExist {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+const (
+	createReceipt     = `INSERT INTO receipts(warehouse_id, supplier_ref, status) VALUES ($1, $2, 'open') RETURNING id, created_at`
+	createReceiptLine = `INSERT INTO receipt_lines(receipt_id, good_id, expected) VALUES ($1, $2, $3)`
+)
+
+func (pg *PostgresConn) CreateReceipt(ctx context.Context, warehouseID int, supplierRef string, lines []domain.GoodQuantity) (domain.Receipt, error) {
+	isExist, err := pg.warehouseIsExist(ctx, warehouseID)
+	if err != nil {
+		return domain.Receipt{}, err
+	}
+	if !isExist {
+		return domain.Receipt{}, ErrIsNotExist
+	}
+
+	if isExist, err = pg.goodsIsExist(ctx, lines); err != nil {
+		return domain.Receipt{}, err
+	}
+	if !isExist {
+		return domain.Receipt{}, ErrIsNotExist
+	}
+
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return domain.Receipt{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rc := domain.Receipt{WarehouseID: warehouseID, SupplierRef: supplierRef, Status: domain.ReceiptOpen}
+	if err = tx.QueryRow(ctx, createReceipt, warehouseID, supplierRef).Scan(&rc.ID, &rc.CreatedAt); err != nil {
+		return domain.Receipt{}, fmt.Errorf("error create receipt: %w", err)
+	}
+
+	for _, l := range lines {
+		if _, err = tx.Exec(ctx, createReceiptLine, rc.ID, l.GoodID, l.Quantity); err != nil {
+			return domain.Receipt{}, fmt.Errorf("error create receipt line: %w", err)
+		}
+	}
+
+	if rc.Lines, err = selectReceiptLines(ctx, tx, rc.ID); err != nil {
+		return domain.Receipt{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return domain.Receipt{}, err
+	}
+	return rc, nil
+}
+
+const (
+	getReceipt      = `SELECT id, warehouse_id, supplier_ref, status, created_at, closed_at FROM receipts WHERE id = $1`
+	lockReceipt     = getReceipt + ` FOR UPDATE`
+	getReceiptLines = `SELECT good_id, expected, received, received - expected FROM receipt_lines WHERE receipt_id = $1 ORDER BY good_id`
+	receiveLine     = `INSERT INTO receipt_lines(receipt_id, good_id, expected, received) VALUES ($1, $2, 0, $3)
+ON CONFLICT (receipt_id, good_id) DO UPDATE SET received = receipt_lines.received + EXCLUDED.received`
+	closeReceipt = `UPDATE receipts SET status = 'closed', closed_at = now() WHERE id = $1 RETURNING closed_at`
+)
+
+func scanReceipt(row pgx.Row) (domain.Receipt, error) {
+	rc := domain.Receipt{}
+	err := row.Scan(&rc.ID, &rc.WarehouseID, &rc.SupplierRef, &rc.Status, &rc.CreatedAt, &rc.ClosedAt)
+	return rc, err
+}
+
+func selectReceiptLines(ctx context.Context, q querier, receiptID int) ([]domain.ReceiptLine, error) {
+	rows, err := q.Query(ctx, getReceiptLines, receiptID)
+	if err != nil {
+		return nil, fmt.Errorf("error get lines of receipt with id = %d: %w", receiptID, err)
+	}
+
+	lines, err := pgx.CollectRows(rows, pgx.RowToStructByPos[domain.ReceiptLine])
+	if err != nil {
+		return nil, fmt.Errorf("error collect lines of receipt with id = %d: %w", receiptID, err)
+	}
+	return lines, nil
+}
+
+func (pg *PostgresConn) lockOpenReceipt(ctx context.Context, tx pgx.Tx, id int) (domain.Receipt, error) {
+	rc, err := scanReceipt(tx.QueryRow(ctx, lockReceipt, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Receipt{}, ErrNotFound
+		}
+		return domain.Receipt{}, fmt.Errorf("error lock receipt with id = %d: %w", id, err)
+	}
+	if rc.Status != domain.ReceiptOpen {
+		return domain.Receipt{}, ErrReceiptIsClosed
+	}
+	return rc, nil
+}
+
+func (pg *PostgresConn) GetReceipt(ctx context.Context, id int) (domain.Receipt, error) {
+	rc, err := scanReceipt(pg.pool.QueryRow(ctx, getReceipt, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Receipt{}, ErrNotFound
+		}
+		return domain.Receipt{}, fmt.Errorf("error get receipt with id = %d: %w", id, err)
+	}
+
+	if rc.Lines, err = selectReceiptLines(ctx, pg.pool, id); err != nil {
+		return domain.Receipt{}, err
+	}
+	return rc, nil
+}
+
+// ReceiveReceipt принимает часть поставки: количество добавляется к принятому по строке и к count на складе.
+// Товар, которого не было в поставке, принимается строкой с expected = 0
+func (pg *PostgresConn) ReceiveReceipt(ctx context.Context, id int, lines []domain.GoodQuantity) (domain.Receipt, error) {
+	isExist, err := pg.goodsIsExist(ctx, lines)
+	if err != nil {
+		return domain.Receipt{}, err
+	}
+	if !isExist {
+		return domain.Receipt{}, ErrIsNotExist
+	}
+
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return domain.Receipt{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rc, err := pg.lockOpenReceipt(ctx, tx, id)
+	if err != nil {
+		return domain.Receipt{}, err
+	}
+
+	if err = lockAvailableWarehouse(ctx, tx, rc.WarehouseID); err != nil {
+		return domain.Receipt{}, err
+	}
+
+	if err = pg.checkCapacity(ctx, tx, rc.WarehouseID, lines); err != nil {
+		return domain.Receipt{}, err
+	}
+
+	if err = pg.checkNotKits(ctx, tx, lines); err != nil {
+		return domain.Receipt{}, err
+	}
+
+	for _, l := range lines {
+		if _, err = tx.Exec(ctx, receiveLine, id, l.GoodID, l.Quantity); err != nil {
+			return domain.Receipt{}, fmt.Errorf("error receive good %d: %w", l.GoodID, err)
+		}
+
+		if err = pg.changeStock(ctx, tx, domain.StockMovement{
+			GoodID:      l.GoodID,
+			WarehouseID: rc.WarehouseID,
+			Type:        domain.MovementReceipt,
+			CountDelta:  l.Quantity,
+			Reference:   receiptReference(id),
+		}); err != nil {
+			return domain.Receipt{}, err
+		}
+
+		if l.Lot != nil {
+			if err = pg.addLot(ctx, tx, l.GoodID, rc.WarehouseID, *l.Lot, l.Quantity); err != nil {
+				return domain.Receipt{}, err
+			}
+		}
+
+		if err = pg.registerSerials(ctx, tx, l.GoodID, rc.WarehouseID, l.Serials, domain.SerialEventReceipt, receiptReference(id)); err != nil {
+			return domain.Receipt{}, err
+		}
+	}
+
+	if rc.Lines, err = selectReceiptLines(ctx, tx, id); err != nil {
+		return domain.Receipt{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return domain.Receipt{}, err
+	}
+	return rc, nil
+}
+
+// CloseReceipt закрывает поставку, строки с Variance != 0 - отчет о пере- и недопоставке
+func (pg *PostgresConn) CloseReceipt(ctx context.Context, id int) (domain.Receipt, error) {
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return domain.Receipt{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	rc, err := pg.lockOpenReceipt(ctx, tx, id)
+	if err != nil {
+		return domain.Receipt{}, err
+	}
+
+	if err = tx.QueryRow(ctx, closeReceipt, id).Scan(&rc.ClosedAt); err != nil {
+		return domain.Receipt{}, fmt.Errorf("error close receipt with id = %d: %w", id, err)
+	}
+	rc.Status = domain.ReceiptClosed
+
+	if rc.Lines, err = selectReceiptLines(ctx, tx, id); err != nil {
+		return domain.Receipt{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return domain.Receipt{}, err
+	}
+	return rc, nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"warehouse/internal/core/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const getStockDemand = `SELECT gw.good_id, gw.warehouse_id, gw.count - gw.reserved, COALESCE(t.quantity, 0), COALESCE(m.quantity, 0)
+FROM goods_warehouse gw
+LEFT JOIN (
+    SELECT good_id, warehouse_id, -SUM(count_delta) AS quantity FROM stock_movements
+    WHERE type = 'fulfil' AND created_at >= $1 GROUP BY good_id, warehouse_id
+) m ON m.good_id = gw.good_id AND m.warehouse_id = gw.warehouse_id
+LEFT JOIN (
+    SELECT good_id, to_warehouse_id AS warehouse_id, SUM(quantity) AS quantity FROM transfers
+    WHERE status = 'in_transit' GROUP BY good_id, to_warehouse_id
+) t ON t.good_id = gw.good_id AND t.warehouse_id = gw.warehouse_id`
+
+// GetStockDemand возвращает свободный остаток и отгруженное с since количество по строкам goods_warehouse
+func (pg *PostgresConn) GetStockDemand(ctx context.Context, goodID, warehouseID int, since time.Time) ([]domain.StockDemand, error) {
+	where := make([]string, 0, 2)
+	args := []any{since}
+	if goodID > 0 {
+		args = append(args, goodID)
+		where = append(where, fmt.Sprintf("gw.good_id = $%d", len(args)))
+	}
+	if warehouseID > 0 {
+		args = append(args, warehouseID)
+		where = append(where, fmt.Sprintf("gw.warehouse_id = $%d", len(args)))
+	}
+
+	query := getStockDemand
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY gw.warehouse_id, gw.good_id"
+
+	rows, err := pg.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error get stock demand: %w", err)
+	}
+
+	demand, err := pgx.CollectRows(rows, pgx.RowToStructByPos[domain.StockDemand])
+	if err != nil {
+		return nil, fmt.Errorf("error collect stock demand: %w", err)
+	}
+	return demand, nil
+}
@@ -15,6 +15,38 @@ var ( // errors
 	ErrIsNotExist                 = errors.New("is not exist")
 	ErrFailedCheckGoodInWarehouse = errors.New("good in this warehouse does not exist")
 	ErrReserve                    = errors.New("error reserve")
+	ErrNotEnoughGoods             = errors.New("not enough free goods in this warehouse")
+	ErrNotEnoughReserved          = errors.New("not enough reserved goods in this warehouse")
+	ErrReservationIsNotActive     = errors.New("reservation is not active")
+	ErrReservationIsAmbiguous     = errors.New("several active reservations hold this good in this warehouse")
+	ErrWarehouseIsUnavailable     = errors.New("warehouse is unavailable")
+	ErrTransferIsNotInTransit     = errors.New("transfer is not in transit")
+	ErrBelowReserved              = errors.New("count can not be less than reserved")
+	ErrCountSessionIsNotOpen      = errors.New("count session is not open")
+	ErrCountSessionIsExist        = errors.New("open count session for this warehouse already exist")
+	ErrReceiptIsClosed            = errors.New("receipt is closed")
+	ErrOrderIsExist               = errors.New("order for this reservation already exist")
+	ErrOrderIsNotPicking          = errors.New("order is not picking")
+	ErrReservationHasOrder        = errors.New("reservation is being picked by an order")
+	ErrPickExceedsQuantity        = errors.New("picked quantity exceeds quantity in pick list")
+	ErrReturnExceedsShipped       = errors.New("returned quantity exceeds shipped quantity")
+	ErrLotExpiryMismatch          = errors.New("lot already exist with another expiry date")
+	ErrGoodIsNotSerialTracked     = errors.New("good is not serial tracked")
+	ErrSerialsIsRequired          = errors.New("serials are required for serial tracked good")
+	ErrSerialIsExist              = errors.New("serial is already in stock")
+	ErrSerialIsNotAvailable       = errors.New("serial is not in stock in this warehouse")
+	ErrSerialCountMismatch        = errors.New("number of serials does not match quantity")
+	ErrNotEnoughUnbinned          = errors.New("not enough unbinned goods in this warehouse")
+	ErrNotEnoughInBin             = errors.New("not enough goods in this bin")
+	ErrBinsInDifferentWarehouses  = errors.New("bins are in different warehouses")
+	ErrBinNotFound                = errors.New("bin is not found")
+	ErrBinIsNotInWarehouse        = errors.New("bin is not in this warehouse")
+	ErrCapacityExceeded           = errors.New("warehouse capacity exceeded")
+	ErrGoodIsKit                  = errors.New("good is a kit")
+	ErrKitHasStock                = errors.New("good has its own stock")
+	ErrNestedKit                  = errors.New("kit can not contain other kits or be a component")
+	ErrIdempotencyKeyInProgress   = errors.New("request with this idempotency key is in progress")
+	ErrIdempotencyKeyMismatch     = errors.New("idempotency key is used with another request")
 )
 
 type PostgresConn struct {
@@ -0,0 +1,454 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"warehouse/internal/core/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const createReservation = `INSERT INTO reservations(order_ref, status, expires_at) VALUES ($1, 'active', $2) RETURNING id`
+
+func (pg *PostgresConn) createReservation(ctx context.Context, q querier, orderRef string, ttl time.Duration) (int, error) {
+	var expiresAt *time.Time
+	if ttl > 0 {
+		t := time.Now().Add(ttl)
+		expiresAt = &t
+	}
+
+	id := 0
+	if err := q.QueryRow(ctx, createReservation, orderRef, expiresAt).Scan(&id); err != nil {
+		return 0, fmt.Errorf("error create reservation: %w", err)
+	}
+	return id, nil
+}
+
+const deleteReservation = `DELETE FROM reservations WHERE id = $1`
+
+func (pg *PostgresConn) deleteReservation(ctx context.Context, id int) error {
+	if _, err := pg.pool.Exec(ctx, deleteReservation, id); err != nil {
+		return fmt.Errorf("error delete reservation with id = %d: %w", id, err)
+	}
+	return nil
+}
+
+const createReservationLine = `INSERT INTO reservation_lines(reservation_id, good_id, warehouse_id, quantity) VALUES ($1, $2, $3, $4)`
+
+const getReservation = `SELECT id, order_ref, status, created_at, expires_at FROM reservations WHERE id = $1`
+
+func (pg *PostgresConn) GetReservation(ctx context.Context, id int) (domain.Reservation, error) {
+	row := pg.pool.QueryRow(ctx, getReservation, id)
+
+	r := domain.Reservation{}
+	if err := row.Scan(&r.ID, &r.OrderRef, &r.Status, &r.CreatedAt, &r.ExpiresAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Reservation{}, ErrNotFound
+		}
+		return domain.Reservation{}, fmt.Errorf("error get reservation with id = %d: %w", id, err)
+	}
+
+	lines, err := selectReservationLines(ctx, pg.pool, id)
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+	r.Lines = lines
+
+	return r, nil
+}
+
+const getReservationLines = `SELECT good_id, warehouse_id, quantity FROM reservation_lines WHERE reservation_id = $1 ORDER BY warehouse_id, good_id`
+
+// querier позволяет выполнять одни и те же запросы как через пул, так и внутри транзакции
+type querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func selectReservationLines(ctx context.Context, q querier, reservationID int) ([]domain.ReservationLine, error) {
+	rows, err := q.Query(ctx, getReservationLines, reservationID)
+	if err != nil {
+		return nil, fmt.Errorf("error get lines of reservation with id = %d: %w", reservationID, err)
+	}
+
+	defer rows.Close()
+
+	lines := make([]domain.ReservationLine, 0)
+
+	for rows.Next() {
+		l := domain.ReservationLine{}
+
+		if err = rows.Scan(&l.GoodID, &l.WarehouseID, &l.Quantity); err != nil {
+			return nil, fmt.Errorf("error scan from rows: %w", err)
+		}
+
+		lines = append(lines, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return lines, nil
+}
+
+const lockReservation = `SELECT status FROM reservations WHERE id = $1 FOR UPDATE`
+
+func (pg *PostgresConn) lockActiveReservation(ctx context.Context, tx pgx.Tx, id int) error {
+	status := domain.ReservationStatus("")
+	if err := tx.QueryRow(ctx, lockReservation, id).Scan(&status); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("error lock reservation with id = %d: %w", id, err)
+	}
+
+	if status != domain.ReservationActive {
+		return ErrReservationIsNotActive
+	}
+	return nil
+}
+
+const checkPickingOrder = `SELECT EXISTS(SELECT 1 FROM orders WHERE reservation_id = $1 AND status = 'picking')`
+
+// lockReleasableReservation блокирует активную резервацию, которую можно снять или отгрузить в обход заказа.
+// Резервацию, по которой собирается заказ, снимает и отгружает только сборка, иначе заказ останется без товара.
+// CreateOrder блокирует ту же строку резервации, поэтому заказ не появится до конца tx
+func (pg *PostgresConn) lockReleasableReservation(ctx context.Context, tx pgx.Tx, id int) error {
+	if err := pg.lockActiveReservation(ctx, tx, id); err != nil {
+		return err
+	}
+
+	var hasOrder bool
+	if err := tx.QueryRow(ctx, checkPickingOrder, id).Scan(&hasOrder); err != nil {
+		return fmt.Errorf("error check order of reservation with id = %d: %w", id, err)
+	}
+	if hasOrder {
+		return ErrReservationHasOrder
+	}
+	return nil
+}
+
+const setReservationStatus = `UPDATE reservations SET status = $2 WHERE id = $1`
+
+// releaseReservationLines возвращает зарезервированные по строкам резервации единицы,
+// закрепленные партии и серийные номера. reserved меньше строки означает, что учет разошелся,
+// такая резервация не снимается
+func (pg *PostgresConn) releaseReservationLines(ctx context.Context, tx pgx.Tx, reservationID int, lines []domain.ReservationLine, reason string) error {
+	for _, l := range lines {
+		gw, isExist, err := pg.lockGoodInWarehouse(ctx, tx, l.WarehouseID, l.GoodID)
+		if err != nil {
+			return err
+		}
+		if !isExist || gw.Reserved < l.Quantity {
+			return fmt.Errorf("reservation with id = %d, good %d in warehouse %d: %w", reservationID, l.GoodID, l.WarehouseID, ErrNotEnoughReserved)
+		}
+		if err = pg.changeStock(ctx, tx, domain.StockMovement{
+			GoodID:        l.GoodID,
+			WarehouseID:   l.WarehouseID,
+			Type:          domain.MovementRelease,
+			ReservedDelta: -l.Quantity,
+			Reason:        reason,
+			Reference:     reservationReference(reservationID),
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := pg.releaseReservedLots(ctx, tx, reservationID); err != nil {
+		return err
+	}
+	return pg.releaseReservedSerials(ctx, tx, reservationID)
+}
+
+const (
+	getPairReservations = `SELECT DISTINCT reservation_lines.reservation_id FROM reservation_lines
+INNER JOIN reservations ON reservation_lines.reservation_id = reservations.id
+WHERE reservations.status = 'active' AND reservation_lines.good_id = $1 AND reservation_lines.warehouse_id = $2
+ORDER BY reservation_lines.reservation_id LIMIT 2`
+	lockPairLines = `SELECT id, quantity FROM reservation_lines
+WHERE reservation_id = $1 AND good_id = $2 AND warehouse_id = $3 ORDER BY id DESC FOR UPDATE`
+	deleteReservationLine = `DELETE FROM reservation_lines WHERE id = $1`
+	shrinkReservationLine = `UPDATE reservation_lines SET quantity = quantity - $2 WHERE id = $1`
+	checkReservationLines = `SELECT EXISTS(SELECT 1 FROM reservation_lines WHERE reservation_id = $1)`
+)
+
+// pairReservation находит резервацию, с которой снимается резерв пары: указанную в паре
+// или единственную активную резервацию, в строках которой есть пара
+func (pg *PostgresConn) pairReservation(ctx context.Context, q querier, pair domain.PairGoodWarehouse) (int, error) {
+	if pair.ReservationID > 0 {
+		return pair.ReservationID, nil
+	}
+
+	rows, err := q.Query(ctx, getPairReservations, pair.GoodID, pair.WarehouseID)
+	if err != nil {
+		return 0, fmt.Errorf("error get reservations of good %d in warehouse %d: %w", pair.GoodID, pair.WarehouseID, err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return 0, fmt.Errorf("error scan from rows: %w", err)
+	}
+
+	switch len(ids) {
+	case 0:
+		return 0, ErrNotEnoughReserved
+	case 1:
+		return ids[0], nil
+	default:
+		return 0, ErrReservationIsAmbiguous
+	}
+}
+
+// releaseKitPairInTx снимает резерв комплекта. Строки резервации комплекта записаны по компонентам,
+// поэтому с одной резервации снимаются c.Quantity * pair.Quantity единиц каждого компонента.
+// Без указанной резервации берется единственная активная резервация первого компонента
+func (pg *PostgresConn) releaseKitPairInTx(ctx context.Context, tx pgx.Tx, pair *domain.PairGoodWarehouse, components []domain.KitComponent) error {
+	componentPairs, err := kitComponentPairs(*pair, components)
+	if err != nil {
+		return err
+	}
+
+	reservationID, err := pg.pairReservation(ctx, tx, componentPairs[0])
+	if err != nil {
+		return err
+	}
+	for i := range componentPairs {
+		componentPairs[i].ReservationID = reservationID
+		if err = pg.releasePairInTx(ctx, tx, &componentPairs[i]); err != nil {
+			return err
+		}
+	}
+	pair.ReservationID = reservationID
+	return nil
+}
+
+// releasePair снимает резерв пары в отдельной транзакции
+func (pg *PostgresConn) releasePair(ctx context.Context, pair *domain.PairGoodWarehouse) error {
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = pg.releasePairInTx(ctx, tx, pair); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// releasePairInTx снимает quantity единиц пары с одной резервации: уменьшает ее строки, партии и серийные номера
+// вместе с reserved. Резервация без строк становится released. В pair.ReservationID записывается резервация
+func (pg *PostgresConn) releasePairInTx(ctx context.Context, tx pgx.Tx, pair *domain.PairGoodWarehouse) error {
+	components, err := getKitComponents(ctx, tx, pair.GoodID)
+	if err != nil {
+		return err
+	}
+	if len(components) > 0 {
+		return pg.releaseKitPairInTx(ctx, tx, pair, components)
+	}
+
+	reservationID, err := pg.pairReservation(ctx, tx, *pair)
+	if err != nil {
+		return err
+	}
+
+	if err = pg.lockReleasableReservation(ctx, tx, reservationID); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(ctx, lockPairLines, reservationID, pair.GoodID, pair.WarehouseID)
+	if err != nil {
+		return fmt.Errorf("error lock lines of reservation with id = %d: %w", reservationID, err)
+	}
+	type lineQuantity struct {
+		ID       int
+		Quantity int
+	}
+	lines, err := pgx.CollectRows(rows, pgx.RowToStructByPos[lineQuantity])
+	if err != nil {
+		return fmt.Errorf("error collect lines of reservation with id = %d: %w", reservationID, err)
+	}
+	reservedTotal := 0
+	for _, l := range lines {
+		reservedTotal += l.Quantity
+	}
+	if reservedTotal < pair.Quantity {
+		return ErrNotEnoughReserved
+	}
+
+	gw, isExist, err := pg.lockGoodInWarehouse(ctx, tx, pair.WarehouseID, pair.GoodID)
+	if err != nil {
+		return err
+	}
+	if !isExist || gw.Reserved < pair.Quantity {
+		return fmt.Errorf("reservation with id = %d, good %d in warehouse %d: %w", reservationID, pair.GoodID, pair.WarehouseID, ErrNotEnoughReserved)
+	}
+
+	if err = pg.releasePairLots(ctx, tx, reservationID, pair.GoodID, pair.WarehouseID, reservedTotal, pair.Quantity); err != nil {
+		return err
+	}
+	if err = pg.releasePairSerials(ctx, tx, reservationID, *pair); err != nil {
+		return err
+	}
+
+	rest := pair.Quantity
+	for _, l := range lines {
+		if rest == 0 {
+			break
+		}
+		if l.Quantity <= rest {
+			_, err = tx.Exec(ctx, deleteReservationLine, l.ID)
+		} else {
+			_, err = tx.Exec(ctx, shrinkReservationLine, l.ID, rest)
+		}
+		if err != nil {
+			return fmt.Errorf("error shrink line of reservation with id = %d: %w", reservationID, err)
+		}
+		rest -= min(rest, l.Quantity)
+	}
+
+	if err = pg.changeStock(ctx, tx, domain.StockMovement{
+		GoodID:        pair.GoodID,
+		WarehouseID:   pair.WarehouseID,
+		Type:          domain.MovementRelease,
+		ReservedDelta: -pair.Quantity,
+		Reason:        "release by pair",
+		Reference:     reservationReference(reservationID),
+	}); err != nil {
+		return err
+	}
+
+	var hasLines bool
+	if err = tx.QueryRow(ctx, checkReservationLines, reservationID).Scan(&hasLines); err != nil {
+		return fmt.Errorf("error check lines of reservation with id = %d: %w", reservationID, err)
+	}
+	if !hasLines {
+		if _, err = tx.Exec(ctx, setReservationStatus, reservationID, domain.ReservationReleased); err != nil {
+			return fmt.Errorf("error set status of reservation with id = %d: %w", reservationID, err)
+		}
+	}
+
+	pair.ReservationID = reservationID
+	return nil
+}
+
+func (pg *PostgresConn) ReleaseReservationByID(ctx context.Context, id int) (domain.MetaInfoReleaseReservation, error) {
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return domain.MetaInfoReleaseReservation{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = pg.lockReleasableReservation(ctx, tx, id); err != nil {
+		return domain.MetaInfoReleaseReservation{}, err
+	}
+
+	lines, err := selectReservationLines(ctx, tx, id)
+	if err != nil {
+		return domain.MetaInfoReleaseReservation{}, err
+	}
+
+	if err = pg.releaseReservationLines(ctx, tx, id, lines, "released"); err != nil {
+		return domain.MetaInfoReleaseReservation{}, err
+	}
+
+	if _, err = tx.Exec(ctx, setReservationStatus, id, domain.ReservationReleased); err != nil {
+		return domain.MetaInfoReleaseReservation{}, fmt.Errorf("error set status of reservation with id = %d: %w", id, err)
+	}
+
+	ans := domain.MetaInfoReleaseReservation{
+		ReleasedReservations: make([]domain.PairGoodWarehouse, 0, len(lines)),
+		ErrorRelease:         make([]domain.PairGoodWarehouse, 0),
+	}
+	for _, l := range lines {
+		ans.ReleasedReservations = append(ans.ReleasedReservations, domain.PairGoodWarehouse{
+			GoodID:      l.GoodID,
+			WarehouseID: l.WarehouseID,
+			Quantity:    l.Quantity,
+		})
+	}
+
+	if err = pg.saveIdempotencyResponse(ctx, tx, ans); err != nil {
+		return domain.MetaInfoReleaseReservation{}, err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return domain.MetaInfoReleaseReservation{}, err
+	}
+	return ans, nil
+}
+
+const getExpiredReservations = `SELECT id FROM reservations WHERE status = 'active' AND expires_at <= now()
+AND NOT EXISTS(SELECT 1 FROM orders WHERE orders.reservation_id = reservations.id AND orders.status = 'picking')
+AND id <> ALL($2::INTEGER[])
+ORDER BY expires_at LIMIT $1`
+
+// ExpireReservations переводит просроченные активные резервации в статус expired и
+// возвращает зарезервированные по ним единицы. Каждая резервация обрабатывается
+// в своей транзакции, чтобы не держать блокировки на много строк goods_warehouse сразу.
+// Резервация, которую не удалось снять, не останавливает остальные: ее id возвращается в failed,
+// а ошибки всех таких резерваций - одной ошибкой после прохода. Резервации из skip не выбираются
+func (pg *PostgresConn) ExpireReservations(ctx context.Context, limit int, skip []int) (int, []int, error) {
+	if skip == nil {
+		skip = []int{}
+	}
+	rows, err := pg.pool.Query(ctx, getExpiredReservations, limit, skip)
+	if err != nil {
+		return 0, nil, fmt.Errorf("error get expired reservations: %w", err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return 0, nil, fmt.Errorf("error scan from rows: %w", err)
+	}
+
+	expired := 0
+	failed := make([]int, 0)
+	errs := make([]error, 0)
+	for _, id := range ids {
+		ok, err := pg.expireReservation(ctx, id)
+		if err != nil {
+			failed = append(failed, id)
+			errs = append(errs, fmt.Errorf("error expire reservation with id = %d: %w", id, err))
+			continue
+		}
+		if ok {
+			expired++
+		}
+	}
+	return expired, failed, errors.Join(errs...)
+}
+
+func (pg *PostgresConn) expireReservation(ctx context.Context, id int) (bool, error) {
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = pg.lockReleasableReservation(ctx, tx, id); err != nil {
+		if errors.Is(err, ErrReservationIsNotActive) || errors.Is(err, ErrNotFound) || errors.Is(err, ErrReservationHasOrder) {
+			// резервацию успели снять, выполнить или отдать в сборку, пока мы до нее добрались
+			return false, nil
+		}
+		return false, err
+	}
+
+	lines, err := selectReservationLines(ctx, tx, id)
+	if err != nil {
+		return false, err
+	}
+
+	if err = pg.releaseReservationLines(ctx, tx, id, lines, "expired"); err != nil {
+		return false, err
+	}
+
+	if _, err = tx.Exec(ctx, setReservationStatus, id, domain.ReservationExpired); err != nil {
+		return false, fmt.Errorf("error set status of reservation with id = %d: %w", id, err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
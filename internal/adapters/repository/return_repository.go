@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"warehouse/internal/core/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	lockShipment       = `SELECT id FROM shipments WHERE id = $1 FOR UPDATE`
+	getReturnableGoods = `SELECT s.good_id, s.quantity - COALESCE(r.quantity, 0) FROM
+(SELECT good_id, SUM(quantity) AS quantity FROM shipment_lines WHERE shipment_id = $1 GROUP BY good_id) s
+LEFT JOIN (
+    SELECT rl.good_id, SUM(rl.quantity) AS quantity FROM return_lines rl JOIN returns r ON r.id = rl.return_id
+    WHERE r.shipment_id = $1 GROUP BY rl.good_id
+) r ON r.good_id = s.good_id`
+	createReturn     = `INSERT INTO returns(shipment_id, warehouse_id, reason) VALUES ($1, $2, $3) RETURNING id, created_at`
+	createReturnLine = `INSERT INTO return_lines(return_id, good_id, disposition, quantity) VALUES ($1, $2, $3, $4)`
+)
+
+// returnableGoods - сколько единиц каждого товара отгрузки еще можно вернуть
+func returnableGoods(ctx context.Context, tx pgx.Tx, shipmentID int) (map[int]int, error) {
+	rows, err := tx.Query(ctx, getReturnableGoods, shipmentID)
+	if err != nil {
+		return nil, fmt.Errorf("error get returnable goods of shipment with id = %d: %w", shipmentID, err)
+	}
+
+	returnable := make(map[int]int)
+	var goodID, quantity int
+	_, err = pgx.ForEachRow(rows, []any{&goodID, &quantity}, func() error {
+		returnable[goodID] = quantity
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error collect returnable goods of shipment with id = %d: %w", shipmentID, err)
+	}
+	return returnable, nil
+}
+
+// CreateReturn принимает возврат по отгрузке. Вернуть можно не больше, чем отгружено за вычетом прошлых возвратов.
+// В продаваемый остаток попадают только строки restock, quarantine - в карантинный, scrap хранится только в возврате.
+// Серийные номера строк должны быть отгружены по этой отгрузке
+func (pg *PostgresConn) CreateReturn(ctx context.Context, req domain.ReturnRequest) (domain.Return, error) {
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return domain.Return{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = lockAvailableWarehouse(ctx, tx, req.WarehouseID); err != nil {
+		return domain.Return{}, err
+	}
+
+	if err = tx.QueryRow(ctx, lockShipment, req.ShipmentID).Scan(new(int)); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Return{}, ErrNotFound
+		}
+		return domain.Return{}, fmt.Errorf("error lock shipment with id = %d: %w", req.ShipmentID, err)
+	}
+
+	returnable, err := returnableGoods(ctx, tx, req.ShipmentID)
+	if err != nil {
+		return domain.Return{}, err
+	}
+	for _, l := range req.Lines {
+		returnable[l.GoodID] -= l.Quantity
+		if returnable[l.GoodID] < 0 {
+			return domain.Return{}, ErrReturnExceedsShipped
+		}
+	}
+
+	rt := domain.Return{
+		ShipmentID:  req.ShipmentID,
+		WarehouseID: req.WarehouseID,
+		Reason:      req.Reason,
+		Lines:       req.Lines,
+	}
+	if err = tx.QueryRow(ctx, createReturn, rt.ShipmentID, rt.WarehouseID, rt.Reason).Scan(&rt.ID, &rt.CreatedAt); err != nil {
+		return domain.Return{}, fmt.Errorf("error create return: %w", err)
+	}
+
+	for _, l := range rt.Lines {
+		if _, err = tx.Exec(ctx, createReturnLine, rt.ID, l.GoodID, l.Disposition, l.Quantity); err != nil {
+			return domain.Return{}, fmt.Errorf("error create return line: %w", err)
+		}
+
+		if err = pg.returnSerials(ctx, tx, rt, l); err != nil {
+			return domain.Return{}, err
+		}
+
+		m := domain.StockMovement{
+			GoodID:      l.GoodID,
+			WarehouseID: rt.WarehouseID,
+			Type:        domain.MovementReturn,
+			Reason:      rt.Reason,
+			Reference:   returnReference(rt.ID),
+		}
+		switch l.Disposition {
+		case domain.DispositionRestock:
+			m.CountDelta = l.Quantity
+		case domain.DispositionQuarantine:
+			m.QuarantinedDelta = l.Quantity
+		default:
+			// списанный возврат на склад не попадает
+			continue
+		}
+		if err = pg.changeStock(ctx, tx, m); err != nil {
+			return domain.Return{}, err
+		}
+		if l.Disposition == domain.DispositionRestock {
+			if err = pg.restockLots(ctx, tx, rt, l); err != nil {
+				return domain.Return{}, err
+			}
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return domain.Return{}, err
+	}
+	return rt, nil
+}
+
+const (
+	// партии, из которых собрана отгрузка, за вычетом единиц, уже вернувшихся в них по прошлым возвратам
+	getReturnableLots = `SELECT lots.number, lots.expires_at, SUM(reservation_lots.quantity) - COALESCE((
+    SELECT SUM(return_lots.quantity) FROM return_lots INNER JOIN returns ON return_lots.return_id = returns.id
+    WHERE returns.shipment_id = $1 AND return_lots.good_id = $2 AND return_lots.number = lots.number AND return_lots.expires_at = lots.expires_at
+), 0)
+FROM shipments INNER JOIN reservation_lots ON reservation_lots.reservation_id = shipments.reservation_id
+INNER JOIN lots ON reservation_lots.lot_id = lots.id
+WHERE shipments.id = $1 AND lots.good_id = $2
+GROUP BY lots.number, lots.expires_at ORDER BY lots.expires_at, lots.number`
+	createReturnLot = `INSERT INTO return_lots(return_id, good_id, number, expires_at, quantity) VALUES ($1, $2, $3, $4, $5)`
+)
+
+// restockLots возвращает единицы строки restock в партии, из которых они были отгружены, с тем же сроком годности.
+// Единицы сверх отгруженных партий возвращаются вне партий
+func (pg *PostgresConn) restockLots(ctx context.Context, tx pgx.Tx, rt domain.Return, l domain.ReturnLine) error {
+	rows, err := tx.Query(ctx, getReturnableLots, rt.ShipmentID, l.GoodID)
+	if err != nil {
+		return fmt.Errorf("error get lots of shipment with id = %d: %w", rt.ShipmentID, err)
+	}
+	lots, err := pgx.CollectRows(rows, pgx.RowToStructByPos[movedLot])
+	if err != nil {
+		return fmt.Errorf("error collect lots of shipment with id = %d: %w", rt.ShipmentID, err)
+	}
+
+	rest := l.Quantity
+	for _, lot := range lots {
+		if rest == 0 {
+			break
+		}
+		q := min(rest, lot.Quantity)
+		if q <= 0 {
+			continue
+		}
+		if err = pg.addLot(ctx, tx, l.GoodID, rt.WarehouseID, domain.LotRef{Number: lot.Number, ExpiresAt: lot.ExpiresAt}, q); err != nil {
+			return err
+		}
+		if _, err = tx.Exec(ctx, createReturnLot, rt.ID, l.GoodID, lot.Number, lot.ExpiresAt, q); err != nil {
+			return fmt.Errorf("error create lot %s of return: %w", lot.Number, err)
+		}
+		rest -= q
+	}
+	return nil
+}
+
+const (
+	getReturn      = `SELECT id, shipment_id, warehouse_id, reason, created_at FROM returns WHERE id = $1`
+	getReturnLines = `SELECT good_id, quantity, disposition FROM return_lines WHERE return_id = $1 ORDER BY good_id, disposition`
+)
+
+func (pg *PostgresConn) GetReturn(ctx context.Context, id int) (domain.Return, error) {
+	rt := domain.Return{}
+	err := pg.pool.QueryRow(ctx, getReturn, id).Scan(&rt.ID, &rt.ShipmentID, &rt.WarehouseID, &rt.Reason, &rt.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Return{}, ErrNotFound
+		}
+		return domain.Return{}, fmt.Errorf("error get return with id = %d: %w", id, err)
+	}
+
+	rows, err := pg.pool.Query(ctx, getReturnLines, id)
+	if err != nil {
+		return domain.Return{}, fmt.Errorf("error get lines of return with id = %d: %w", id, err)
+	}
+	rt.Lines, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.ReturnLine, error) {
+		l := domain.ReturnLine{}
+		err := row.Scan(&l.GoodID, &l.Quantity, &l.Disposition)
+		return l, err
+	})
+	if err != nil {
+		return domain.Return{}, fmt.Errorf("error collect lines of return with id = %d: %w", id, err)
+	}
+	return rt, nil
+}
@@ -0,0 +1,347 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"warehouse/internal/core/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const getGoodSerialTracked = `SELECT serial_tracked FROM goods WHERE id = $1`
+
+func goodIsSerialTracked(ctx context.Context, q querier, goodID int) (bool, error) {
+	var tracked bool
+	if err := q.QueryRow(ctx, getGoodSerialTracked, goodID).Scan(&tracked); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, ErrIsNotExist
+		}
+		return false, fmt.Errorf("error check serial tracking of good %d: %w", goodID, err)
+	}
+	return tracked, nil
+}
+
+const (
+	// отгруженный или списанный номер можно принять повторно, например после возврата поставщику
+	registerSerial = `INSERT INTO serials(good_id, number, warehouse_id, status) VALUES ($1, $2, $3, 'in_stock')
+ON CONFLICT (number) DO UPDATE SET warehouse_id = EXCLUDED.warehouse_id, status = 'in_stock', reservation_id = NULL, shipment_id = NULL
+WHERE serials.status IN ('shipped', 'written_off') AND serials.good_id = EXCLUDED.good_id
+RETURNING id`
+	createSerialEvent = `INSERT INTO serial_events(serial_id, type, warehouse_id, reference, actor) VALUES ($1, $2, $3, $4, $5)`
+)
+
+// registerSerials записывает принятые или оприходованные серийные номера на склад событием event.
+// Для товара с серийным учетом номера обязательны, их число сверяется в сервисе
+func (pg *PostgresConn) registerSerials(ctx context.Context, tx pgx.Tx, goodID, warehouseID int, serials []string,
+	event domain.SerialEventType, reference string) error {
+	tracked, err := goodIsSerialTracked(ctx, tx, goodID)
+	if err != nil {
+		return err
+	}
+	if !tracked {
+		if len(serials) > 0 {
+			return ErrGoodIsNotSerialTracked
+		}
+		return nil
+	}
+	if len(serials) == 0 {
+		return ErrSerialsIsRequired
+	}
+
+	for _, number := range serials {
+		var id int
+		if err = tx.QueryRow(ctx, registerSerial, goodID, number, warehouseID).Scan(&id); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("serial %s: %w", number, ErrSerialIsExist)
+			}
+			return fmt.Errorf("error register serial %s: %w", number, err)
+		}
+		if _, err = tx.Exec(ctx, createSerialEvent, id, event, warehouseID, reference, domain.ActorFromContext(ctx)); err != nil {
+			return fmt.Errorf("error create event of serial %s: %w", number, err)
+		}
+	}
+	return nil
+}
+
+const (
+	lockSerialsByNumber = `SELECT id, number FROM serials
+WHERE good_id = $1 AND warehouse_id = $2 AND status = $4 AND number = ANY($3) ORDER BY id FOR UPDATE`
+	lockFreeSerials = `SELECT id, number FROM serials
+WHERE good_id = $1 AND warehouse_id = $2 AND status = $4 ORDER BY id LIMIT $3 FOR UPDATE`
+	reserveSerial = `UPDATE serials SET status = 'reserved', reservation_id = $2 WHERE id = $1`
+)
+
+type serialRef struct {
+	ID     int
+	Number string
+}
+
+// lockSerials блокирует номера товара на складе в статусе status: переданные или первые quantity по порядку приемки.
+// Если номеров не хватает, возвращает ErrSerialIsNotAvailable для переданных и ErrNotEnoughGoods для подбираемых
+func lockSerials(ctx context.Context, tx pgx.Tx, goodID, warehouseID int, status domain.SerialStatus, serials []string, quantity int) ([]serialRef, error) {
+	var rows pgx.Rows
+	var err error
+	if len(serials) > 0 {
+		rows, err = tx.Query(ctx, lockSerialsByNumber, goodID, warehouseID, serials, status)
+	} else {
+		rows, err = tx.Query(ctx, lockFreeSerials, goodID, warehouseID, quantity, status)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error lock serials of good %d in warehouse %d: %w", goodID, warehouseID, err)
+	}
+	locked, err := pgx.CollectRows(rows, pgx.RowToStructByPos[serialRef])
+	if err != nil {
+		return nil, fmt.Errorf("error collect serials of good %d in warehouse %d: %w", goodID, warehouseID, err)
+	}
+	if len(locked) < quantity {
+		if len(serials) > 0 {
+			return nil, ErrSerialIsNotAvailable
+		}
+		// остаток есть, но номера по нему не зарегистрированы
+		return nil, ErrNotEnoughGoods
+	}
+	return locked, nil
+}
+
+// reserveSerials закрепляет за резервацией серийные номера пары: переданные или первые свободные.
+// Вызывается до изменения reserved в goods_warehouse, ничего не пишет, если номеров не хватает.
+// Возвращает закрепленные номера, для товара без серийного учета - nil
+func (pg *PostgresConn) reserveSerials(ctx context.Context, tx pgx.Tx, reservationID int, pair domain.PairGoodWarehouse) ([]string, error) {
+	tracked, err := goodIsSerialTracked(ctx, tx, pair.GoodID)
+	if err != nil {
+		return nil, err
+	}
+	if !tracked {
+		if len(pair.Serials) > 0 {
+			return nil, ErrSerialIsNotAvailable
+		}
+		return nil, nil
+	}
+
+	serials, err := lockSerials(ctx, tx, pair.GoodID, pair.WarehouseID, domain.SerialInStock, pair.Serials, pair.Quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	numbers := make([]string, 0, len(serials))
+	for _, s := range serials {
+		if _, err = tx.Exec(ctx, reserveSerial, s.ID, reservationID); err != nil {
+			return nil, fmt.Errorf("error reserve serial %s: %w", s.Number, err)
+		}
+		if _, err = tx.Exec(ctx, createSerialEvent, s.ID, domain.SerialEventReserve, pair.WarehouseID,
+			reservationReference(reservationID), domain.ActorFromContext(ctx)); err != nil {
+			return nil, fmt.Errorf("error create event of serial %s: %w", s.Number, err)
+		}
+		numbers = append(numbers, s.Number)
+	}
+	return numbers, nil
+}
+
+const (
+	releaseReservedSerials = `WITH released AS (
+    UPDATE serials SET status = 'in_stock', reservation_id = NULL
+    WHERE reservation_id = $1 AND status = 'reserved' RETURNING id, warehouse_id
+)
+INSERT INTO serial_events(serial_id, type, warehouse_id, reference, actor)
+SELECT id, 'release', warehouse_id, $2, $3 FROM released`
+	shipReservedSerials = `WITH shipped AS (
+    UPDATE serials SET status = 'shipped', shipment_id = $2, warehouse_id = NULL
+    FROM serials s WHERE s.id = serials.id AND serials.reservation_id = $1 AND serials.status = 'reserved'
+    RETURNING serials.id, s.warehouse_id
+)
+INSERT INTO serial_events(serial_id, type, warehouse_id, reference, actor)
+SELECT id, 'ship', warehouse_id, $3, $4 FROM shipped`
+)
+
+// releaseReservedSerials возвращает в свободный остаток номера, закрепленные за резервацией
+func (pg *PostgresConn) releaseReservedSerials(ctx context.Context, tx pgx.Tx, reservationID int) error {
+	if _, err := tx.Exec(ctx, releaseReservedSerials, reservationID,
+		reservationReference(reservationID), domain.ActorFromContext(ctx)); err != nil {
+		return fmt.Errorf("error release serials of reservation with id = %d: %w", reservationID, err)
+	}
+	return nil
+}
+
+const releasePairSerials = `WITH released AS (
+    UPDATE serials SET status = 'in_stock', reservation_id = NULL
+    WHERE id IN (
+        SELECT id FROM serials WHERE reservation_id = $1 AND good_id = $2 AND warehouse_id = $3 AND status = 'reserved'
+        AND ($4::text[] IS NULL OR number = ANY($4)) ORDER BY id DESC LIMIT $5 FOR UPDATE
+    ) RETURNING id, warehouse_id
+)
+INSERT INTO serial_events(serial_id, type, warehouse_id, reference, actor)
+SELECT id, 'release', warehouse_id, $6, $7 FROM released`
+
+// releasePairSerials возвращает в свободный остаток номера пары при частичном снятии резервации:
+// переданные в pair.Serials или последние закрепленные
+func (pg *PostgresConn) releasePairSerials(ctx context.Context, tx pgx.Tx, reservationID int, pair domain.PairGoodWarehouse) error {
+	tracked, err := goodIsSerialTracked(ctx, tx, pair.GoodID)
+	if err != nil {
+		return err
+	}
+	if !tracked {
+		if len(pair.Serials) > 0 {
+			return ErrSerialIsNotAvailable
+		}
+		return nil
+	}
+
+	var numbers []string
+	if len(pair.Serials) > 0 {
+		numbers = pair.Serials
+	}
+	tag, err := tx.Exec(ctx, releasePairSerials, reservationID, pair.GoodID, pair.WarehouseID, numbers, pair.Quantity,
+		reservationReference(reservationID), domain.ActorFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("error release serials of reservation with id = %d: %w", reservationID, err)
+	}
+	if int(tag.RowsAffected()) != pair.Quantity {
+		return ErrSerialIsNotAvailable
+	}
+	return nil
+}
+
+// shipReservedSerials помечает номера резервации отгруженными. Склад, с которого ушел номер, остается в истории
+func (pg *PostgresConn) shipReservedSerials(ctx context.Context, tx pgx.Tx, reservationID, shipmentID int) error {
+	if _, err := tx.Exec(ctx, shipReservedSerials, reservationID, shipmentID,
+		shipmentReference(shipmentID), domain.ActorFromContext(ctx)); err != nil {
+		return fmt.Errorf("error ship serials of reservation with id = %d: %w", reservationID, err)
+	}
+	return nil
+}
+
+const (
+	moveSerials = `UPDATE serials SET status = $2, warehouse_id = $3, reservation_id = NULL, shipment_id = NULL WHERE id = ANY($1)`
+	// событие пишется складу, с которого или на котором номер изменился
+	createSerialEvents = `INSERT INTO serial_events(serial_id, type, warehouse_id, reference, actor)
+SELECT unnest($1::INTEGER[]), $2, $3, $4, $5`
+)
+
+// serialMove - перевод номеров вместе с изменением остатка товара на складе
+type serialMove struct {
+	GoodID      int
+	WarehouseID int
+	From        domain.SerialStatus
+	To          domain.SerialStatus
+	// ToWarehouseID - склад номера после перевода, nil - номер уходит со складов
+	ToWarehouseID *int
+	Quantity      int
+	// Serials - переводимые номера, пусто - первые по порядку приемки
+	Serials   []string
+	Event     domain.SerialEventType
+	Reference string
+}
+
+// moveSerials переводит номера товара с серийным учетом в той же транзакции, что и остаток.
+// Вызывается до изменения остатка. Возвращает id переведенных номеров, для товара без серийного учета - nil
+func (pg *PostgresConn) moveSerials(ctx context.Context, tx pgx.Tx, m serialMove) ([]int, error) {
+	tracked, err := goodIsSerialTracked(ctx, tx, m.GoodID)
+	if err != nil {
+		return nil, err
+	}
+	if !tracked {
+		if len(m.Serials) > 0 {
+			return nil, ErrGoodIsNotSerialTracked
+		}
+		return nil, nil
+	}
+	if len(m.Serials) > 0 && len(m.Serials) != m.Quantity {
+		return nil, ErrSerialCountMismatch
+	}
+
+	serials, err := lockSerials(ctx, tx, m.GoodID, m.WarehouseID, m.From, m.Serials, m.Quantity)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0, len(serials))
+	for _, s := range serials {
+		ids = append(ids, s.ID)
+	}
+
+	if _, err = tx.Exec(ctx, moveSerials, ids, m.To, m.ToWarehouseID); err != nil {
+		return nil, fmt.Errorf("error move serials of good %d in warehouse %d: %w", m.GoodID, m.WarehouseID, err)
+	}
+	if _, err = tx.Exec(ctx, createSerialEvents, ids, m.Event, m.WarehouseID, m.Reference, domain.ActorFromContext(ctx)); err != nil {
+		return nil, fmt.Errorf("error create events of serials of good %d: %w", m.GoodID, err)
+	}
+	return ids, nil
+}
+
+const lockShippedSerials = `SELECT id FROM serials
+WHERE good_id = $1 AND shipment_id = $2 AND status = 'shipped' AND number = ANY($3) ORDER BY id FOR UPDATE`
+
+// returnSerials принимает на склад возврата номера строки, отгруженные по отгрузке возврата.
+// restock возвращает номера в свободный остаток, quarantine - в карантин, scrap списывает
+func (pg *PostgresConn) returnSerials(ctx context.Context, tx pgx.Tx, rt domain.Return, l domain.ReturnLine) error {
+	tracked, err := goodIsSerialTracked(ctx, tx, l.GoodID)
+	if err != nil {
+		return err
+	}
+	if !tracked {
+		if len(l.Serials) > 0 {
+			return ErrGoodIsNotSerialTracked
+		}
+		return nil
+	}
+	if len(l.Serials) == 0 {
+		return ErrSerialsIsRequired
+	}
+	if len(l.Serials) != l.Quantity {
+		return ErrSerialCountMismatch
+	}
+
+	rows, err := tx.Query(ctx, lockShippedSerials, l.GoodID, rt.ShipmentID, l.Serials)
+	if err != nil {
+		return fmt.Errorf("error lock serials of shipment with id = %d: %w", rt.ShipmentID, err)
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return fmt.Errorf("error collect serials of shipment with id = %d: %w", rt.ShipmentID, err)
+	}
+	if len(ids) < l.Quantity {
+		return ErrSerialIsNotAvailable
+	}
+
+	status, warehouseID := domain.SerialInStock, &rt.WarehouseID
+	switch l.Disposition {
+	case domain.DispositionQuarantine:
+		status = domain.SerialQuarantined
+	case domain.DispositionScrap:
+		status, warehouseID = domain.SerialWrittenOff, nil
+	}
+	if _, err = tx.Exec(ctx, moveSerials, ids, status, warehouseID); err != nil {
+		return fmt.Errorf("error return serials of good %d: %w", l.GoodID, err)
+	}
+	if _, err = tx.Exec(ctx, createSerialEvents, ids, domain.SerialEventReturn, rt.WarehouseID,
+		returnReference(rt.ID), domain.ActorFromContext(ctx)); err != nil {
+		return fmt.Errorf("error create events of serials of good %d: %w", l.GoodID, err)
+	}
+	return nil
+}
+
+const (
+	getSerial       = `SELECT id, good_id, number, warehouse_id, status, reservation_id, shipment_id FROM serials WHERE number = $1`
+	getSerialEvents = `SELECT type, warehouse_id, reference, actor, created_at FROM serial_events WHERE serial_id = $1 ORDER BY id`
+)
+
+// GetSerial возвращает текущее положение серийного номера и всю его историю
+func (pg *PostgresConn) GetSerial(ctx context.Context, number string) (domain.Serial, error) {
+	s := domain.Serial{}
+	if err := pg.pool.QueryRow(ctx, getSerial, number).Scan(&s.ID, &s.GoodID, &s.Number, &s.WarehouseID,
+		&s.Status, &s.ReservationID, &s.ShipmentID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Serial{}, ErrNotFound
+		}
+		return domain.Serial{}, fmt.Errorf("error get serial %s: %w", number, err)
+	}
+
+	rows, err := pg.pool.Query(ctx, getSerialEvents, s.ID)
+	if err != nil {
+		return domain.Serial{}, fmt.Errorf("error get events of serial %s: %w", number, err)
+	}
+	if s.Events, err = pgx.CollectRows(rows, pgx.RowToStructByPos[domain.SerialEvent]); err != nil {
+		return domain.Serial{}, fmt.Errorf("error collect events of serial %s: %w", number, err)
+	}
+	return s, nil
+}
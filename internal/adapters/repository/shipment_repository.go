@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"warehouse/internal/core/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// fulfilLine списывает со склада зарезервированные единицы строки отгрузки: собранные - из ячеек,
+// где их взяли, остаток - из ячеек товара на складе: сначала из приемки, затем по id ячейки
+func (pg *PostgresConn) fulfilLine(ctx context.Context, tx pgx.Tx, shipmentID int, line domain.ShipmentLine, picks []domain.Pick) error {
+	if err := shareAvailableWarehouse(ctx, tx, line.WarehouseID); err != nil {
+		return err
+	}
+
+	gw, isExist, err := pg.lockGoodInWarehouse(ctx, tx, line.WarehouseID, line.GoodID)
+	if err != nil {
+		return err
+	}
+
+	if !isExist {
+		return ErrFailedCheckGoodInWarehouse
+	}
+
+	if gw.Reserved < line.Quantity {
+		// строки резервации разошлись с reserved
+		return ErrNotEnoughReserved
+	}
+
+	rest := line.Quantity
+	for _, p := range picks {
+		if err = pg.changeStock(ctx, tx, domain.StockMovement{
+			GoodID:        line.GoodID,
+			WarehouseID:   line.WarehouseID,
+			Type:          domain.MovementFulfil,
+			CountDelta:    -p.Quantity,
+			ReservedDelta: -p.Quantity,
+			BinID:         p.BinID,
+			Reference:     shipmentReference(shipmentID),
+		}); err != nil {
+			return err
+		}
+		rest -= p.Quantity
+	}
+	if rest == 0 {
+		return nil
+	}
+
+	return pg.changeStock(ctx, tx, domain.StockMovement{
+		GoodID:        line.GoodID,
+		WarehouseID:   line.WarehouseID,
+		Type:          domain.MovementFulfil,
+		CountDelta:    -rest,
+		ReservedDelta: -rest,
+		Reference:     shipmentReference(shipmentID),
+	})
+}
+
+type pickKey struct {
+	GoodID      int
+	WarehouseID int
+}
+
+// groupPicks раскладывает взятые из ячеек единицы по товару и складу ячейки
+func groupPicks(ctx context.Context, tx pgx.Tx, picks []domain.Pick) (map[pickKey][]domain.Pick, error) {
+	grouped := make(map[pickKey][]domain.Pick, len(picks))
+	for _, p := range picks {
+		warehouseID, err := binWarehouse(ctx, tx, p.BinID)
+		if err != nil {
+			return nil, err
+		}
+		key := pickKey{GoodID: p.GoodID, WarehouseID: warehouseID}
+		grouped[key] = append(grouped[key], p)
+	}
+	return grouped, nil
+}
+
+// takePicks забирает из picks единицы для строки в quantity единиц и возвращает их вместе с оставшимися
+func takePicks(picks []domain.Pick, quantity int) ([]domain.Pick, []domain.Pick) {
+	taken := make([]domain.Pick, 0, len(picks))
+	for len(picks) > 0 && quantity > 0 {
+		p := picks[0]
+		if p.Quantity > quantity {
+			taken = append(taken, domain.Pick{GoodID: p.GoodID, BinID: p.BinID, Quantity: quantity})
+			picks[0].Quantity -= quantity
+			break
+		}
+		taken = append(taken, p)
+		quantity -= p.Quantity
+		picks = picks[1:]
+	}
+	return taken, picks
+}
+
+const createShipment = `INSERT INTO shipments(reservation_id) VALUES ($1) RETURNING id, created_at`
+const createShipmentLine = `INSERT INTO shipment_lines(shipment_id, good_id, warehouse_id, quantity) VALUES ($1, $2, $3, $4)`
+
+func (pg *PostgresConn) createShipment(ctx context.Context, tx pgx.Tx, reservationID int, lines []domain.ShipmentLine) (domain.Shipment, error) {
+	sh := domain.Shipment{
+		ReservationID: reservationID,
+		Lines:         lines,
+	}
+	if err := tx.QueryRow(ctx, createShipment, reservationID).Scan(&sh.ID, &sh.CreatedAt); err != nil {
+		return domain.Shipment{}, fmt.Errorf("error create shipment: %w", err)
+	}
+
+	for _, l := range lines {
+		if _, err := tx.Exec(ctx, createShipmentLine, sh.ID, l.GoodID, l.WarehouseID, l.Quantity); err != nil {
+			return domain.Shipment{}, fmt.Errorf("error create shipment line: %w", err)
+		}
+	}
+	return sh, nil
+}
+
+// fulfilReservation отгружает все строки уже заблокированной активной резервации внутри tx:
+// уменьшает count и reserved и записывает отгрузку. picks - единицы, взятые из названных ячеек;
+// больше, чем зарезервировано товара на складе ячейки, взять нельзя
+func (pg *PostgresConn) fulfilReservation(ctx context.Context, tx pgx.Tx, reservationID int, picks []domain.Pick) (domain.Shipment, error) {
+	reservationLines, err := selectReservationLines(ctx, tx, reservationID)
+	if err != nil {
+		return domain.Shipment{}, err
+	}
+
+	grouped, err := groupPicks(ctx, tx, picks)
+	if err != nil {
+		return domain.Shipment{}, err
+	}
+
+	lines := make([]domain.ShipmentLine, 0, len(reservationLines))
+	for _, l := range reservationLines {
+		lines = append(lines, domain.ShipmentLine{
+			GoodID:      l.GoodID,
+			WarehouseID: l.WarehouseID,
+			Quantity:    l.Quantity,
+		})
+	}
+
+	sh, err := pg.createShipment(ctx, tx, reservationID, lines)
+	if err != nil {
+		return domain.Shipment{}, err
+	}
+
+	for _, line := range lines {
+		key := pickKey{GoodID: line.GoodID, WarehouseID: line.WarehouseID}
+		var linePicks []domain.Pick
+		linePicks, grouped[key] = takePicks(grouped[key], line.Quantity)
+		if err = pg.fulfilLine(ctx, tx, sh.ID, line, linePicks); err != nil {
+			return domain.Shipment{}, err
+		}
+	}
+	for _, rest := range grouped {
+		if len(rest) > 0 {
+			return domain.Shipment{}, ErrPickExceedsQuantity
+		}
+	}
+
+	if err = pg.consumeReservedLots(ctx, tx, reservationID); err != nil {
+		return domain.Shipment{}, err
+	}
+
+	if err = pg.shipReservedSerials(ctx, tx, reservationID, sh.ID); err != nil {
+		return domain.Shipment{}, err
+	}
+
+	if _, err = tx.Exec(ctx, setReservationStatus, reservationID, domain.ReservationFulfilled); err != nil {
+		return domain.Shipment{}, fmt.Errorf("error set status of reservation with id = %d: %w", reservationID, err)
+	}
+	return sh, nil
+}
+
+// FulfilReservation отгружает все строки активной резервации в одной транзакции.
+// Резервация, по которой собирается заказ, отгружается только сборкой
+func (pg *PostgresConn) FulfilReservation(ctx context.Context, reservationID int, picks []domain.Pick) (domain.Shipment, error) {
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return domain.Shipment{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = pg.lockReleasableReservation(ctx, tx, reservationID); err != nil {
+		return domain.Shipment{}, err
+	}
+
+	sh, err := pg.fulfilReservation(ctx, tx, reservationID, picks)
+	if err != nil {
+		return domain.Shipment{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return domain.Shipment{}, err
+	}
+	return sh, nil
+}
+
+const getShipment = `SELECT id, reservation_id, created_at FROM shipments WHERE id = $1`
+const getShipmentLines = `SELECT good_id, warehouse_id, quantity FROM shipment_lines WHERE shipment_id = $1 ORDER BY warehouse_id, good_id`
+
+func (pg *PostgresConn) GetShipment(ctx context.Context, id int) (domain.Shipment, error) {
+	sh := domain.Shipment{}
+	if err := pg.pool.QueryRow(ctx, getShipment, id).Scan(&sh.ID, &sh.ReservationID, &sh.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Shipment{}, ErrNotFound
+		}
+		return domain.Shipment{}, fmt.Errorf("error get shipment with id = %d: %w", id, err)
+	}
+
+	rows, err := pg.pool.Query(ctx, getShipmentLines, id)
+	if err != nil {
+		return domain.Shipment{}, fmt.Errorf("error get lines of shipment with id = %d: %w", id, err)
+	}
+	lines, err := pgx.CollectRows(rows, pgx.RowToStructByPos[domain.ShipmentLine])
+	if err != nil {
+		return domain.Shipment{}, fmt.Errorf("error scan from rows: %w", err)
+	}
+	sh.Lines = lines
+
+	return sh, nil
+}
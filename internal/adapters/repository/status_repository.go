@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"warehouse/internal/core/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// statusAvailable - сколько единиц можно увести из статуса. Из продаваемого остатка - только свободные
+func (gw goodInWarehouse) statusAvailable(status domain.StockStatus) int {
+	switch status {
+	case domain.StockQuarantined:
+		return gw.Quarantined
+	case domain.StockDamaged:
+		return gw.Damaged
+	default:
+		return gw.Count - gw.Reserved
+	}
+}
+
+func addStatusDelta(m *domain.StockMovement, status domain.StockStatus, delta int) {
+	switch status {
+	case domain.StockQuarantined:
+		m.QuarantinedDelta += delta
+	case domain.StockDamaged:
+		m.DamagedDelta += delta
+	default:
+		m.CountDelta += delta
+	}
+}
+
+// statusSerialStatus - статус серийного номера единицы в статусе остатка. Продаваемые номера свободны
+func statusSerialStatus(status domain.StockStatus) domain.SerialStatus {
+	switch status {
+	case domain.StockQuarantined:
+		return domain.SerialQuarantined
+	case domain.StockDamaged:
+		return domain.SerialDamaged
+	default:
+		return domain.SerialInStock
+	}
+}
+
+// MoveStockStatus переводит единицы между статусами одной строки goods_warehouse,
+// общее количество товара на складе не меняется. Серийные номера переходят в тот же статус
+func (pg *PostgresConn) MoveStockStatus(ctx context.Context, req domain.StatusMoveRequest) (domain.StockLevel, error) {
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return domain.StockLevel{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	gw, isExist, err := pg.lockGoodInWarehouse(ctx, tx, req.WarehouseID, req.GoodID)
+	if err != nil {
+		return domain.StockLevel{}, err
+	}
+	if !isExist {
+		return domain.StockLevel{}, ErrFailedCheckGoodInWarehouse
+	}
+	if gw.statusAvailable(req.From) < req.Quantity {
+		return domain.StockLevel{}, ErrNotEnoughGoods
+	}
+
+	if _, err = pg.moveSerials(ctx, tx, serialMove{
+		GoodID:        req.GoodID,
+		WarehouseID:   req.WarehouseID,
+		From:          statusSerialStatus(req.From),
+		To:            statusSerialStatus(req.To),
+		ToWarehouseID: &req.WarehouseID,
+		Quantity:      req.Quantity,
+		Serials:       req.Serials,
+		Event:         domain.SerialEventStatus,
+		Reference:     fmt.Sprintf("%s->%s", req.From, req.To),
+	}); err != nil {
+		return domain.StockLevel{}, err
+	}
+
+	m := domain.StockMovement{
+		GoodID:      req.GoodID,
+		WarehouseID: req.WarehouseID,
+		Type:        domain.MovementStatus,
+		Reason:      req.Reason,
+		Reference:   fmt.Sprintf("%s->%s", req.From, req.To),
+	}
+	addStatusDelta(&m, req.From, -req.Quantity)
+	addStatusDelta(&m, req.To, req.Quantity)
+
+	if err = pg.changeStock(ctx, tx, m); err != nil {
+		return domain.StockLevel{}, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return domain.StockLevel{}, err
+	}
+
+	return domain.StockLevel{
+		GoodID:      req.GoodID,
+		WarehouseID: req.WarehouseID,
+		Count:       gw.Count + m.CountDelta,
+		Reserved:    gw.Reserved,
+		Quarantined: gw.Quarantined + m.QuarantinedDelta,
+		Damaged:     gw.Damaged + m.DamagedDelta,
+	}, nil
+}
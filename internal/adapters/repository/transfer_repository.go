@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"warehouse/internal/core/domain"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const createTransfer = `INSERT INTO transfers(good_id, from_warehouse_id, to_warehouse_id, quantity, status, received_at)
+VALUES ($1, $2, $3, $4, $5, CASE WHEN $5 = 'received' THEN now() END) RETURNING id, created_at, received_at`
+
+// Transfer переносит свободные единицы товара между складами в одной транзакции.
+// Склады, а затем строки goods_warehouse блокируются в порядке id склада, чтобы встречные переносы
+// не блокировали друг друга. Если InTransit, товар списывается с исходного склада сразу,
+// а на склад назначения приходит в ReceiveTransfer
+func (pg *PostgresConn) Transfer(ctx context.Context, req domain.TransferRequest) (domain.Transfer, error) {
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return domain.Transfer{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if req.FromWarehouseID < req.ToWarehouseID {
+		if err = shareAvailableWarehouse(ctx, tx, req.FromWarehouseID); err != nil {
+			return domain.Transfer{}, err
+		}
+	}
+	if err = lockAvailableWarehouse(ctx, tx, req.ToWarehouseID); err != nil {
+		return domain.Transfer{}, err
+	}
+	if req.FromWarehouseID > req.ToWarehouseID {
+		if err = shareAvailableWarehouse(ctx, tx, req.FromWarehouseID); err != nil {
+			return domain.Transfer{}, err
+		}
+	}
+
+	if !req.InTransit {
+		if err = pg.checkCapacity(ctx, tx, req.ToWarehouseID, []domain.GoodQuantity{{GoodID: req.GoodID, Quantity: req.Quantity}}); err != nil {
+			return domain.Transfer{}, err
+		}
+	}
+
+	if req.ToWarehouseID < req.FromWarehouseID {
+		if _, _, err = pg.lockGoodInWarehouse(ctx, tx, req.ToWarehouseID, req.GoodID); err != nil {
+			return domain.Transfer{}, err
+		}
+	}
+
+	src, isExist, err := pg.lockGoodInWarehouse(ctx, tx, req.FromWarehouseID, req.GoodID)
+	if err != nil {
+		return domain.Transfer{}, err
+	}
+	if !isExist {
+		return domain.Transfer{}, ErrFailedCheckGoodInWarehouse
+	}
+	if src.Count-src.Reserved < req.Quantity {
+		return domain.Transfer{}, ErrNotEnoughGoods
+	}
+
+	if req.ToWarehouseID > req.FromWarehouseID {
+		if _, _, err = pg.lockGoodInWarehouse(ctx, tx, req.ToWarehouseID, req.GoodID); err != nil {
+			return domain.Transfer{}, err
+		}
+	}
+
+	t := domain.Transfer{
+		GoodID:          req.GoodID,
+		FromWarehouseID: req.FromWarehouseID,
+		ToWarehouseID:   req.ToWarehouseID,
+		Quantity:        req.Quantity,
+		Status:          domain.TransferReceived,
+	}
+	if req.InTransit {
+		t.Status = domain.TransferInTransit
+	}
+
+	if err = tx.QueryRow(ctx, createTransfer, t.GoodID, t.FromWarehouseID, t.ToWarehouseID, t.Quantity, t.Status).
+		Scan(&t.ID, &t.CreatedAt, &t.ReceivedAt); err != nil {
+		return domain.Transfer{}, fmt.Errorf("error create transfer: %w", err)
+	}
+
+	lots, err := pg.takeLots(ctx, tx, t.GoodID, t.FromWarehouseID, t.Quantity)
+	if err != nil {
+		return domain.Transfer{}, err
+	}
+	for _, l := range lots {
+		if _, err = tx.Exec(ctx, createTransferLot, t.ID, l.Number, l.ExpiresAt, l.Quantity); err != nil {
+			return domain.Transfer{}, fmt.Errorf("error create lot %s of transfer: %w", l.Number, err)
+		}
+	}
+
+	serials, err := pg.moveSerials(ctx, tx, serialMove{
+		GoodID:      t.GoodID,
+		WarehouseID: t.FromWarehouseID,
+		From:        domain.SerialInStock,
+		To:          domain.SerialInTransit,
+		Quantity:    t.Quantity,
+		Serials:     req.Serials,
+		Event:       domain.SerialEventTransferOut,
+		Reference:   transferReference(t.ID),
+	})
+	if err != nil {
+		return domain.Transfer{}, err
+	}
+	if len(serials) > 0 {
+		if _, err = tx.Exec(ctx, createTransferSerials, t.ID, serials); err != nil {
+			return domain.Transfer{}, fmt.Errorf("error create serials of transfer: %w", err)
+		}
+	}
+
+	if err = pg.changeStock(ctx, tx, domain.StockMovement{
+		GoodID:      t.GoodID,
+		WarehouseID: t.FromWarehouseID,
+		Type:        domain.MovementTransfer,
+		CountDelta:  -t.Quantity,
+		BinID:       req.FromBinID,
+		Reference:   transferReference(t.ID),
+	}); err != nil {
+		return domain.Transfer{}, err
+	}
+
+	if !req.InTransit {
+		if err = pg.receiveTransfer(ctx, tx, t); err != nil {
+			return domain.Transfer{}, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return domain.Transfer{}, err
+	}
+	return t, nil
+}
+
+const (
+	createTransferLot = `INSERT INTO transfer_lots(transfer_id, number, expires_at, quantity) VALUES ($1, $2, $3, $4)`
+	getTransferLots   = `SELECT number, expires_at, quantity FROM transfer_lots WHERE transfer_id = $1 ORDER BY number`
+
+	createTransferSerials  = `INSERT INTO transfer_serials(transfer_id, serial_id) SELECT $1, unnest($2::INTEGER[])`
+	receiveTransferSerials = `WITH received AS (
+    UPDATE serials SET status = 'in_stock', warehouse_id = $2
+    FROM transfer_serials ts WHERE ts.serial_id = serials.id AND ts.transfer_id = $1 AND serials.status = 'in_transit'
+    RETURNING serials.id
+)
+INSERT INTO serial_events(serial_id, type, warehouse_id, reference, actor)
+SELECT id, 'transfer_in', $2, $3, $4 FROM received`
+)
+
+// receiveTransfer приходует перенос на склад назначения вместе с партиями и серийными номерами,
+// ушедшими с исходного склада
+func (pg *PostgresConn) receiveTransfer(ctx context.Context, tx pgx.Tx, t domain.Transfer) error {
+	if err := pg.changeStock(ctx, tx, domain.StockMovement{
+		GoodID:      t.GoodID,
+		WarehouseID: t.ToWarehouseID,
+		Type:        domain.MovementTransfer,
+		CountDelta:  t.Quantity,
+		Reference:   transferReference(t.ID),
+	}); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(ctx, getTransferLots, t.ID)
+	if err != nil {
+		return fmt.Errorf("error get lots of transfer with id = %d: %w", t.ID, err)
+	}
+	lots, err := pgx.CollectRows(rows, pgx.RowToStructByPos[movedLot])
+	if err != nil {
+		return fmt.Errorf("error collect lots of transfer with id = %d: %w", t.ID, err)
+	}
+	for _, l := range lots {
+		if err = pg.addLot(ctx, tx, t.GoodID, t.ToWarehouseID, domain.LotRef{Number: l.Number, ExpiresAt: l.ExpiresAt}, l.Quantity); err != nil {
+			return err
+		}
+	}
+
+	if _, err = tx.Exec(ctx, receiveTransferSerials, t.ID, t.ToWarehouseID, transferReference(t.ID), domain.ActorFromContext(ctx)); err != nil {
+		return fmt.Errorf("error receive serials of transfer with id = %d: %w", t.ID, err)
+	}
+	return nil
+}
+
+const getTransfer = `SELECT id, good_id, from_warehouse_id, to_warehouse_id, quantity, status, created_at, received_at FROM transfers WHERE id = $1`
+
+func scanTransfer(row pgx.Row) (domain.Transfer, error) {
+	t := domain.Transfer{}
+	err := row.Scan(&t.ID, &t.GoodID, &t.FromWarehouseID, &t.ToWarehouseID, &t.Quantity, &t.Status, &t.CreatedAt, &t.ReceivedAt)
+	return t, err
+}
+
+func (pg *PostgresConn) GetTransfer(ctx context.Context, id int) (domain.Transfer, error) {
+	t, err := scanTransfer(pg.pool.QueryRow(ctx, getTransfer, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Transfer{}, ErrNotFound
+		}
+		return domain.Transfer{}, fmt.Errorf("error get transfer with id = %d: %w", id, err)
+	}
+	return t, nil
+}
+
+const lockTransfer = getTransfer + ` FOR UPDATE`
+const setTransferReceived = `UPDATE transfers SET status = 'received', received_at = now() WHERE id = $1 RETURNING received_at`
+
+// ReceiveTransfer подтверждает приход товара, отправленного в пути, на склад назначения
+func (pg *PostgresConn) ReceiveTransfer(ctx context.Context, id int) (domain.Transfer, error) {
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return domain.Transfer{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	t, err := scanTransfer(tx.QueryRow(ctx, lockTransfer, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Transfer{}, ErrNotFound
+		}
+		return domain.Transfer{}, fmt.Errorf("error lock transfer with id = %d: %w", id, err)
+	}
+
+	if t.Status != domain.TransferInTransit {
+		return domain.Transfer{}, ErrTransferIsNotInTransit
+	}
+
+	if err = lockAvailableWarehouse(ctx, tx, t.ToWarehouseID); err != nil {
+		return domain.Transfer{}, err
+	}
+
+	if err = pg.checkCapacity(ctx, tx, t.ToWarehouseID, []domain.GoodQuantity{{GoodID: t.GoodID, Quantity: t.Quantity}}); err != nil {
+		return domain.Transfer{}, err
+	}
+
+	if _, _, err = pg.lockGoodInWarehouse(ctx, tx, t.ToWarehouseID, t.GoodID); err != nil {
+		return domain.Transfer{}, err
+	}
+
+	if err = pg.receiveTransfer(ctx, tx, t); err != nil {
+		return domain.Transfer{}, err
+	}
+
+	if err = tx.QueryRow(ctx, setTransferReceived, id).Scan(&t.ReceivedAt); err != nil {
+		return domain.Transfer{}, fmt.Errorf("error set transfer with id = %d received: %w", id, err)
+	}
+	t.Status = domain.TransferReceived
+
+	if err = tx.Commit(ctx); err != nil {
+		return domain.Transfer{}, err
+	}
+	return t, nil
+}
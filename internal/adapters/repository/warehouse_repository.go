@@ -9,13 +9,13 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
-const getWarehouse = `SELECT * FROM warehouse WHERE id = $1`
+const getWarehouse = `SELECT id, name, is_available, priority, capacity FROM warehouse WHERE id = $1`
 
 func (pg *PostgresConn) GetWarehouse(ctx context.Context, id int) (domain.Warehouse, error) {
 	row := pg.pool.QueryRow(ctx, getWarehouse, id)
 
 	w := domain.Warehouse{}
-	if err := row.Scan(&w.ID, &w.Name, &w.IsAvailable); err != nil {
+	if err := row.Scan(&w.ID, &w.Name, &w.IsAvailable, &w.Priority, &w.Capacity); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return domain.Warehouse{}, ErrNotFound
 		}
@@ -32,7 +32,7 @@ func (pg *PostgresConn) GetWarehouse(ctx context.Context, id int) (domain.Wareho
 	return w, nil
 }
 
-const getGoodsByWarehouseId = `SELECT goods.name, size, goods.id, count, reserved FROM goods INNER JOIN goods_warehouse ON goods.id = goods_warehouse.good_id INNER JOIN warehouse ON goods_warehouse.warehouse_id = warehouse.id WHERE goods.id = $1`
+const getGoodsByWarehouseId = `SELECT goods.name, size, goods.id, count, reserved, quarantined, damaged FROM goods INNER JOIN goods_warehouse ON goods.id = goods_warehouse.good_id WHERE goods_warehouse.warehouse_id = $1 ORDER BY goods.id`
 
 func (pg *PostgresConn) getGoodsByWarehouseId(ctx context.Context, id int) ([]domain.GoodsWarehouse, error) {
 	rows, err := pg.pool.Query(ctx, getGoodsByWarehouseId, id)
@@ -47,7 +47,7 @@ func (pg *PostgresConn) getGoodsByWarehouseId(ctx context.Context, id int) ([]do
 	for rows.Next() {
 		g := domain.GoodsWarehouse{}
 
-		if err = rows.Scan(&g.Name, &g.Size, &g.ID, &g.Count, &g.Reserved); err != nil {
+		if err = rows.Scan(&g.Name, &g.Size, &g.ID, &g.Count, &g.Reserved, &g.Quarantined, &g.Damaged); err != nil {
 			return nil, fmt.Errorf("error scan from rows: %w", err)
 		}
 
@@ -76,8 +76,48 @@ func (pg *PostgresConn) warehouseIsExist(ctx context.Context, id int) (bool, err
 	return true, nil
 }
 
-const createWarehouse = `INSERT INTO warehouse(name, is_available) VALUES ($1, $2)`
+// FOR NO KEY UPDATE конфликтует со сменой is_available, но не со вставками в журнал движений
+const (
+	lockWarehouseAvailability  = `SELECT is_available FROM warehouse WHERE id = $1 FOR NO KEY UPDATE`
+	shareWarehouseAvailability = `SELECT is_available FROM warehouse WHERE id = $1 FOR SHARE`
+)
+
+// lockAvailableWarehouse блокирует строку склада до конца tx и проверяет, что склад доступен.
+// Пока tx не завершена, склад не может стать недоступным. Вызывается до блокировки строк goods_warehouse
+func lockAvailableWarehouse(ctx context.Context, tx pgx.Tx, id int) error {
+	isAvailable := false
+	if err := tx.QueryRow(ctx, lockWarehouseAvailability, id).Scan(&isAvailable); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrIsNotExist
+		}
+		return fmt.Errorf("error lock warehouse with id = %d: %w", id, err)
+	}
+	if !isAvailable {
+		return ErrWarehouseIsUnavailable
+	}
+	return nil
+}
+
+// shareAvailableWarehouse - lockAvailableWarehouse для расхода со склада: резервации, отгрузки и переноса.
+// Разделяемая блокировка не мешает расходам друг другу, но склад так же не может стать недоступным
+// до конца tx. На складе, которого нет, нет и товара
+func shareAvailableWarehouse(ctx context.Context, tx pgx.Tx, id int) error {
+	isAvailable := false
+	if err := tx.QueryRow(ctx, shareWarehouseAvailability, id).Scan(&isAvailable); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrFailedCheckGoodInWarehouse
+		}
+		return fmt.Errorf("error lock warehouse with id = %d: %w", id, err)
+	}
+	if !isAvailable {
+		return ErrWarehouseIsUnavailable
+	}
+	return nil
+}
 
+const createWarehouse = `INSERT INTO warehouse(name, is_available, priority, capacity) VALUES ($1, $2, $3, $4) RETURNING id`
+
+// CreateWarehouse создает склад вместе с ячейкой приемки, куда попадает принятый товар
 func (pg *PostgresConn) CreateWarehouse(ctx context.Context, warehouse domain.Warehouse) error {
 	isExist, err := pg.warehouseIsExist(ctx, warehouse.ID)
 	if err != nil {
@@ -88,13 +128,23 @@ func (pg *PostgresConn) CreateWarehouse(ctx context.Context, warehouse domain.Wa
 		return ErrIsExist
 	}
 
-	if _, err = pg.pool.Exec(ctx, createWarehouse, warehouse.Name, warehouse.IsAvailable); err != nil {
+	tx, err := pg.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.ReadCommitted})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var id int
+	if err = tx.QueryRow(ctx, createWarehouse, warehouse.Name, warehouse.IsAvailable, warehouse.Priority, warehouse.Capacity).Scan(&id); err != nil {
 		return fmt.Errorf("error create warehouse: %w", err)
 	}
-	return nil
+	if err = createReceivingLocation(ctx, tx, id); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
-const updateWarehouse = `UPDATE warehouse SET name = $1, is_available = $2 WHERE id = $3`
+const updateWarehouse = `UPDATE warehouse SET name = $1, is_available = $2, priority = $3, capacity = $5 WHERE id = $4`
 
 func (pg *PostgresConn) UpdateWarehouse(ctx context.Context, warehouse domain.Warehouse) error {
 	isExist, err := pg.warehouseIsExist(ctx, warehouse.ID)
@@ -106,8 +156,8 @@ func (pg *PostgresConn) UpdateWarehouse(ctx context.Context, warehouse domain.Wa
 		return ErrIsExist
 	}
 
-	if _, err = pg.pool.Exec(ctx, updateWarehouse, warehouse.Name, warehouse.IsAvailable, warehouse.ID); err != nil {
-		return fmt.Errorf("error update warehouse: %w")
+	if _, err = pg.pool.Exec(ctx, updateWarehouse, warehouse.Name, warehouse.IsAvailable, warehouse.Priority, warehouse.ID, warehouse.Capacity); err != nil {
+		return fmt.Errorf("error update warehouse: %w", err)
 	}
 
 	return nil
@@ -132,6 +182,21 @@ func (pg *PostgresConn) DeleteWarehouse(ctx context.Context, id int) error {
 	return nil
 }
 
+const setWarehouseAvailability = `UPDATE warehouse SET is_available = $2 WHERE id = $1`
+
+func (pg *PostgresConn) SetWarehouseAvailability(ctx context.Context, id int, isAvailable bool) error {
+	tag, err := pg.pool.Exec(ctx, setWarehouseAvailability, id, isAvailable)
+	if err != nil {
+		return fmt.Errorf("error set availability of warehouse with id = %d: %w", id, err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrIsNotExist
+	}
+
+	return nil
+}
+
 const getCountGoods = `SELECT SUM(count) FROM goods INNER JOIN goods_warehouse ON goods.id = goods_warehouse.good_id INNER JOIN warehouse ON goods_warehouse.warehouse_id = warehouse.id WHERE warehouse.id = $1 GROUP BY warehouse.id`
 
 func (pg *PostgresConn) GetCountGoods(ctx context.Context, id int) (int, error) {
@@ -5,34 +5,58 @@ import (
 	"net"
 	"net/http"
 	"time"
+	"warehouse/internal/adapters/notifier"
+	"warehouse/internal/config"
+	"warehouse/internal/core/domain"
 	"warehouse/internal/core/ports"
 	"warehouse/internal/core/server"
+	"warehouse/internal/core/services"
 
 	"golang.org/x/sync/errgroup"
 )
 
 type App struct {
 	srv           *http.Server
+	sweeper       *services.ReservationSweeper
 	goodRepo      ports.GoodRepository
 	warehouseRepo ports.WarehouseRepository
+	cfg           config.Config
 }
 
-func NewApp(goodRepo ports.GoodRepository, warehouseRepo ports.WarehouseRepository) (*App, error) {
+func NewApp(goodRepo ports.GoodRepository, warehouseRepo ports.WarehouseRepository, cfg config.Config) (*App, error) {
 	return &App{
 		goodRepo:      goodRepo,
 		warehouseRepo: warehouseRepo,
+		cfg:           cfg,
 	}, nil
 }
 
 func (a *App) Run(ctx context.Context, srvAddr string) error {
 	g, gCtx := errgroup.WithContext(ctx)
-	a.srv = server.NewServer(gCtx, a.goodRepo, a.warehouseRepo, srvAddr)
+	goodService := services.NewGoodService(a.goodRepo, services.GoodServiceConfig{
+		IdempotencyWindow: a.cfg.Idempotency.Window,
+		IdempotencyLease:  a.cfg.Idempotency.Lease,
+		AdjustmentReasons: a.cfg.Adjustment.ReasonCodes,
+		Notifier:          notifier.NewLogNotifier(),
+		Replenishment: domain.ReplenishmentParams{
+			WindowDays:      a.cfg.Replenishment.WindowDays,
+			LeadTimeDays:    a.cfg.Replenishment.LeadTimeDays,
+			SafetyStockDays: a.cfg.Replenishment.SafetyStockDays,
+		},
+		BoxTypes: boxTypes(a.cfg.Packing),
+	})
+	warehouseService := services.NewWarehouseService(a.warehouseRepo)
+	a.srv = server.NewServer(gCtx, goodService, warehouseService, srvAddr)
+	a.sweeper = services.NewReservationSweeper(goodService, a.cfg.Reservation.SweepInterval)
 	g.Go(func() error {
 		a.srv.BaseContext = func(_ net.Listener) context.Context {
 			return gCtx
 		}
 		return a.srv.ListenAndServe()
 	})
+	g.Go(func() error {
+		return a.sweeper.Run(gCtx)
+	})
 	g.Go(func() error {
 		<-gCtx.Done()
 		return a.Close()
@@ -46,7 +70,16 @@ func (a *App) Close() error {
 	if err := a.srv.Shutdown(ctx); err != nil {
 		return err
 	}
+	a.sweeper.Stop()
 	a.goodRepo.Close()
 	a.warehouseRepo.Close()
 	return nil
 }
+
+func boxTypes(cfg config.PackingConfig) []domain.BoxType {
+	types := make([]domain.BoxType, 0, len(cfg.BoxTypes))
+	for _, t := range cfg.BoxTypes {
+		types = append(types, domain.BoxType{Name: t.Name, Capacity: t.Capacity})
+	}
+	return types
+}
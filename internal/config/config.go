@@ -3,13 +3,19 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	DB     DBConfig     `yaml:"db"`
-	Server ServerConfig `yaml:"server"`
+	DB            DBConfig            `yaml:"db"`
+	Server        ServerConfig        `yaml:"server"`
+	Reservation   ReservationConfig   `yaml:"reservation"`
+	Idempotency   IdempotencyConfig   `yaml:"idempotency"`
+	Adjustment    AdjustmentConfig    `yaml:"adjustment"`
+	Replenishment ReplenishmentConfig `yaml:"replenishment"`
+	Packing       PackingConfig       `yaml:"packing"`
 }
 
 type DBConfig struct {
@@ -33,6 +39,34 @@ func (cfg ServerConfig) String() string {
 	return fmt.Sprintf(":%d", cfg.Port)
 }
 
+type ReservationConfig struct {
+	SweepInterval time.Duration `yaml:"sweep_interval"`
+}
+
+type IdempotencyConfig struct {
+	Window time.Duration `yaml:"window"`
+	Lease  time.Duration `yaml:"lease"`
+}
+
+type AdjustmentConfig struct {
+	ReasonCodes []string `yaml:"reason_codes"`
+}
+
+type ReplenishmentConfig struct {
+	WindowDays      int `yaml:"window_days"`
+	LeadTimeDays    int `yaml:"lead_time_days"`
+	SafetyStockDays int `yaml:"safety_stock_days"`
+}
+
+type PackingConfig struct {
+	BoxTypes []BoxTypeConfig `yaml:"box_types"`
+}
+
+type BoxTypeConfig struct {
+	Name     string `yaml:"name"`
+	Capacity int    `yaml:"capacity"`
+}
+
 func Get() (Config, error) {
 	fileName := "config.yaml"
 	cfg := Config{}
@@ -0,0 +1,21 @@
+package domain
+
+import "context"
+
+const AnonymousActor = "anonymous"
+
+type actorKey struct{}
+
+// ContextWithActor сохраняет в контексте того, кто выполняет операцию,
+// чтобы записать его в журнал движений
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) string {
+	actor, ok := ctx.Value(actorKey{}).(string)
+	if !ok || actor == "" {
+		return AnonymousActor
+	}
+	return actor
+}
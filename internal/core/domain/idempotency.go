@@ -0,0 +1,26 @@
+package domain
+
+import "context"
+
+// IdempotencyClaim - ключ идемпотентности, занятый текущим запросом.
+// Репозиторий сохраняет по нему ответ в транзакции операции, поэтому
+// ответ есть у ключа тогда и только тогда, когда операция зафиксирована
+type IdempotencyClaim struct {
+	Key       string
+	Operation string
+	Token     string
+	// Response строит из результата репозитория ответ, который получит повторный запрос.
+	// nil - сохраняется сам результат
+	Response func(result any) ([]byte, error)
+}
+
+type idempotencyClaimKey struct{}
+
+func ContextWithIdempotencyClaim(ctx context.Context, claim IdempotencyClaim) context.Context {
+	return context.WithValue(ctx, idempotencyClaimKey{}, claim)
+}
+
+func IdempotencyClaimFromContext(ctx context.Context) (IdempotencyClaim, bool) {
+	claim, ok := ctx.Value(idempotencyClaimKey{}).(IdempotencyClaim)
+	return claim, ok
+}
@@ -1,41 +1,91 @@
 package domain
 
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
 type Warehouse struct {
 	ID          int              `json:"id"`
 	Name        string           `json:"name"`
-	IsAvailable bool             `json:"is_available"` // забыл реализовать логику доступности/недоступности склада
+	IsAvailable bool             `json:"is_available"` // на недоступном складе нельзя резервировать, принимать и отгружать товары
+	Priority    int              `json:"priority"`     // при автоматическом выборе склада больший приоритет идет первым
+	Capacity    int              `json:"capacity"`     // объем в единицах Good.Size, 0 - без ограничения
 	Goods       []GoodsWarehouse `json:"goods"`
 }
 
 type Good struct {
-	Name       string           `json:"name"`
-	Size       int              `json:"size"`
-	ID         int              `json:"id"`
-	Warehouses []WarehouseGoods `json:"warehouses"`
+	Name            string            `json:"name"`
+	Size            int               `json:"size"`
+	ID              int               `json:"id"`
+	SerialTracked   bool              `json:"serial_tracked"` // каждая единица учитывается по серийному номеру
+	Warehouses      []WarehouseGoods  `json:"warehouses"`
+	Components      []KitComponent    `json:"components,omitempty"`       // непусто у комплекта
+	KitAvailability []KitAvailability `json:"kit_availability,omitempty"` // сколько комплектов собирается из свободных компонентов
 }
 
+// Count - продаваемый остаток, Quarantined и Damaged видны, но не резервируются
 type WarehouseGoods struct {
 	WarehouseName string `json:"name"`
 	IsAvailable   bool   `json:"is_available"`
 	Count         int    `json:"count"`
 	Reserved      int    `json:"reserved"`
+	Quarantined   int    `json:"quarantined"`
+	Damaged       int    `json:"damaged"`
 }
 
 type GoodsWarehouse struct {
-	Name     string `json:"name"`
-	Size     int    `json:"size"`
-	ID       int    `json:"id"`
-	Count    int    `json:"count"`
-	Reserved int    `json:"reserved"`
+	Name        string `json:"name"`
+	Size        int    `json:"size"`
+	ID          int    `json:"id"`
+	Count       int    `json:"count"`
+	Reserved    int    `json:"reserved"`
+	Quarantined int    `json:"quarantined"`
+	Damaged     int    `json:"damaged"`
 }
 
 type PairGoodWarehouse struct {
-	GoodID      int   `json:"good_id"`
-	WarehouseID int   `json:"warehouse_id"`
-	Error       error `json:"error,omitempty"`
+	GoodID        int      `json:"good_id"`
+	WarehouseID   int      `json:"warehouse_id"`
+	Quantity      int      `json:"quantity"`
+	Serials       []string `json:"serials,omitempty"`        // пусто - серийные номера подбираются автоматически
+	ReservationID int      `json:"reservation_id,omitempty"` // при снятии резерва по паре, 0 - единственная активная резервация пары
+	Error         error    `json:"error,omitempty"`
+	ErrorCode     string   `json:"error_code,omitempty"`
+}
+
+// MarshalJSON пишет Error текстом, иначе encoding/json превращает ошибку в {}
+func (p PairGoodWarehouse) MarshalJSON() ([]byte, error) {
+	type pair PairGoodWarehouse
+	v := struct {
+		pair
+		Error string `json:"error,omitempty"`
+	}{pair: pair(p)}
+	if p.Error != nil {
+		v.Error = p.Error.Error()
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON нужен, чтобы сохраненный ответ можно было прочитать обратно
+func (p *PairGoodWarehouse) UnmarshalJSON(data []byte) error {
+	type pair PairGoodWarehouse
+	v := struct {
+		*pair
+		Error string `json:"error,omitempty"`
+	}{pair: (*pair)(p)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v.Error != "" {
+		p.Error = errors.New(v.Error)
+	}
+	return nil
 }
 
 type MetaInfoReservation struct {
+	ReservationID    int                 `json:"reservation_id"`
 	ReservedPairs    []PairGoodWarehouse `json:"reserved"`
 	ErrorReservation []PairGoodWarehouse `json:"error_reservation"`
 }
@@ -44,3 +94,555 @@ type MetaInfoReleaseReservation struct {
 	ReleasedReservations []PairGoodWarehouse `json:"released"`
 	ErrorRelease         []PairGoodWarehouse `json:"error_release"`
 }
+
+// GoodQuantity - строка резервации без склада, склад выбирает сервис
+type GoodQuantity struct {
+	GoodID   int      `json:"good_id"`
+	Quantity int      `json:"quantity"`
+	Lot      *LotRef  `json:"lot,omitempty"`     // учитывается только при приемке
+	Serials  []string `json:"serials,omitempty"` // учитывается только при приемке
+}
+
+type AutoReservationRequest struct {
+	OrderRef string
+	TTL      time.Duration
+	Strategy string
+	Lines    []GoodQuantity
+}
+
+// WarehouseStock - свободный остаток товара на доступном складе
+type WarehouseStock struct {
+	WarehouseID int
+	Free        int
+	Priority    int
+}
+
+type ReservationStatus string
+
+const (
+	ReservationActive    ReservationStatus = "active"
+	ReservationReleased  ReservationStatus = "released"
+	ReservationFulfilled ReservationStatus = "fulfilled"
+	ReservationExpired   ReservationStatus = "expired"
+)
+
+type ReservationRequest struct {
+	OrderRef string
+	TTL      time.Duration // 0 - резервация без срока действия
+	Atomic   bool          // true - резервируются либо все пары, либо ни одной
+	Pairs    []PairGoodWarehouse
+}
+
+type Reservation struct {
+	ID        int               `json:"id"`
+	OrderRef  string            `json:"order_ref,omitempty"` // пусто, если клиент не передал ссылку на заказ
+	Status    ReservationStatus `json:"status"`
+	CreatedAt time.Time         `json:"created_at"`
+	ExpiresAt *time.Time        `json:"expires_at,omitempty"`
+	Lines     []ReservationLine `json:"lines"`
+}
+
+type ReservationLine struct {
+	GoodID      int `json:"good_id"`
+	WarehouseID int `json:"warehouse_id"`
+	Quantity    int `json:"quantity"`
+}
+
+type Shipment struct {
+	ID            int            `json:"id"`
+	ReservationID int            `json:"reservation_id"`
+	CreatedAt     time.Time      `json:"created_at"`
+	Lines         []ShipmentLine `json:"lines"`
+}
+
+type ShipmentLine struct {
+	GoodID      int `json:"good_id"`
+	WarehouseID int `json:"warehouse_id"`
+	Quantity    int `json:"quantity"`
+}
+
+type MovementType string
+
+const (
+	MovementReceipt  MovementType = "receipt"
+	MovementReserve  MovementType = "reserve"
+	MovementRelease  MovementType = "release"
+	MovementFulfil   MovementType = "fulfil"
+	MovementAdjust   MovementType = "adjust"
+	MovementTransfer MovementType = "transfer"
+	MovementReturn   MovementType = "return"
+	MovementStatus   MovementType = "status"
+)
+
+// StockMovement - запись журнала движений: любое изменение остатков в goods_warehouse
+type StockMovement struct {
+	ID               int          `json:"id"`
+	GoodID           int          `json:"good_id"`
+	WarehouseID      int          `json:"warehouse_id"`
+	Type             MovementType `json:"type"`
+	CountDelta       int          `json:"count_delta"`
+	ReservedDelta    int          `json:"reserved_delta"`
+	QuarantinedDelta int          `json:"quarantined_delta"`
+	DamagedDelta     int          `json:"damaged_delta"`
+	Actor            string       `json:"actor"`
+	Reason           string       `json:"reason"`
+	Reference        string       `json:"reference"`
+	CreatedAt        time.Time    `json:"created_at"`
+	// BinID - ячейка, в которой изменился физический остаток. При записи 0 - ячейка приемки для прихода
+	// и ячейки товара по порядку (сначала приемка, затем по id) для расхода. В журнале 0 - физический
+	// остаток не менялся или расход прошел по нескольким ячейкам
+	BinID int `json:"bin_id,omitempty"`
+}
+
+// StockMovementFilter - фильтр журнала, нулевые поля не ограничивают выборку
+type StockMovementFilter struct {
+	GoodID      int
+	WarehouseID int
+	From        time.Time
+	To          time.Time
+	Limit       int
+}
+
+type TransferStatus string
+
+const (
+	TransferInTransit TransferStatus = "in_transit"
+	TransferReceived  TransferStatus = "received"
+)
+
+type TransferRequest struct {
+	GoodID          int
+	FromWarehouseID int
+	ToWarehouseID   int
+	Quantity        int
+	InTransit       bool     // true - товар приходит на склад назначения только после ReceiveTransfer
+	Serials         []string // пусто - серийные номера подбираются автоматически
+	FromBinID       int      // 0 - ячейки товара на исходном складе: сначала приемка, затем по id
+}
+
+type Transfer struct {
+	ID              int            `json:"id"`
+	GoodID          int            `json:"good_id"`
+	FromWarehouseID int            `json:"from_warehouse_id"`
+	ToWarehouseID   int            `json:"to_warehouse_id"`
+	Quantity        int            `json:"quantity"`
+	Status          TransferStatus `json:"status"`
+	CreatedAt       time.Time      `json:"created_at"`
+	ReceivedAt      *time.Time     `json:"received_at,omitempty"`
+}
+
+type AdjustmentRequest struct {
+	GoodID      int
+	WarehouseID int
+	Delta       int
+	Reason      string
+	Serials     []string // приходуемые номера обязательны, списываемые при пустом подбираются автоматически
+	BinID       int      // 0 - ячейка приемки для прихода, ячейки товара по порядку для списания
+}
+
+// StockLevel - остаток товара на складе после операции
+type StockLevel struct {
+	GoodID      int `json:"good_id"`
+	WarehouseID int `json:"warehouse_id"`
+	Count       int `json:"count"`
+	Reserved    int `json:"reserved"`
+	Quarantined int `json:"quarantined"`
+	Damaged     int `json:"damaged"`
+}
+
+type CountSessionStatus string
+
+const (
+	CountSessionOpen      CountSessionStatus = "open"
+	CountSessionApproved  CountSessionStatus = "approved"
+	CountSessionCancelled CountSessionStatus = "cancelled"
+)
+
+// CountSession - сессия инвентаризации склада. Expected фиксируется при открытии,
+// при утверждении расхождения проводятся корректировками остатка
+type CountSession struct {
+	ID          int                `json:"id"`
+	WarehouseID int                `json:"warehouse_id"`
+	Status      CountSessionStatus `json:"status"`
+	CreatedAt   time.Time          `json:"created_at"`
+	ClosedAt    *time.Time         `json:"closed_at,omitempty"`
+	Lines       []CountLine        `json:"lines"`
+}
+
+type CountLine struct {
+	GoodID   int      `json:"good_id"`
+	Expected int      `json:"expected"`
+	Counted  *int     `json:"counted,omitempty"` // nil - товар еще не пересчитан
+	Variance *int     `json:"variance,omitempty"`
+	Serials  []string `json:"serials,omitempty"`
+	BinID    int      `json:"bin_id,omitempty"`
+}
+
+// CountedGood - результат пересчета. Serials - номера излишка или недостачи товара с серийным учетом,
+// BinID - ячейка, в которой найден излишок или не хватает единиц
+type CountedGood struct {
+	GoodID  int      `json:"good_id"`
+	Counted int      `json:"counted"`
+	Serials []string `json:"serials,omitempty"`
+	BinID   int      `json:"bin_id,omitempty"`
+}
+
+// StockKey - строка goods_warehouse
+type StockKey struct {
+	GoodID      int
+	WarehouseID int
+}
+
+// StockThreshold - точка заказа и целевой уровень свободного остатка товара на складе
+type StockThreshold struct {
+	GoodID       int `json:"good_id"`
+	WarehouseID  int `json:"warehouse_id"`
+	ReorderPoint int `json:"reorder_point"`
+	TargetLevel  int `json:"target_level"`
+}
+
+// StockAlert поднимается, когда свободный остаток опускается до точки заказа.
+// На пару товар/склад открыт не больше одного алерта, он закрывается, когда остаток восстановлен
+type StockAlert struct {
+	ID           int        `json:"id"`
+	GoodID       int        `json:"good_id"`
+	WarehouseID  int        `json:"warehouse_id"`
+	Free         int        `json:"free"`
+	ReorderPoint int        `json:"reorder_point"`
+	TargetLevel  int        `json:"target_level"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+}
+
+// StockAlertFilter - фильтр алертов, нулевые поля не ограничивают выборку
+type StockAlertFilter struct {
+	GoodID      int
+	WarehouseID int
+	OpenOnly    bool
+}
+
+// ReplenishmentParams - параметры расчета: окно истории спроса, срок поставки и страховой запас в днях спроса
+type ReplenishmentParams struct {
+	WindowDays      int
+	LeadTimeDays    int
+	SafetyStockDays int
+}
+
+// ReplenishmentRequest - нулевые GoodID/WarehouseID не ограничивают отчет
+type ReplenishmentRequest struct {
+	GoodID      int
+	WarehouseID int
+	Params      ReplenishmentParams
+}
+
+// StockDemand - остаток и отгрузки товара со склада за окно
+type StockDemand struct {
+	GoodID      int
+	WarehouseID int
+	Free        int
+	InTransit   int // едет на склад по незавершенным переносам
+	Outbound    int
+}
+
+type ReplenishmentSuggestion struct {
+	GoodID            int     `json:"good_id"`
+	WarehouseID       int     `json:"warehouse_id"`
+	Free              int     `json:"free"`
+	InTransit         int     `json:"in_transit"`
+	AvgDailyDemand    float64 `json:"avg_daily_demand"`
+	SafetyStock       int     `json:"safety_stock"`
+	ReorderPoint      int     `json:"reorder_point"`
+	SuggestedQuantity int     `json:"suggested_quantity"`
+}
+
+type ReceiptStatus string
+
+const (
+	ReceiptOpen   ReceiptStatus = "open"
+	ReceiptClosed ReceiptStatus = "closed"
+)
+
+// Receipt - ожидаемая поставка (ASN). На остаток влияет только принятое количество
+type Receipt struct {
+	ID          int           `json:"id"`
+	WarehouseID int           `json:"warehouse_id"`
+	SupplierRef string        `json:"supplier_ref"`
+	Status      ReceiptStatus `json:"status"`
+	CreatedAt   time.Time     `json:"created_at"`
+	ClosedAt    *time.Time    `json:"closed_at,omitempty"`
+	Lines       []ReceiptLine `json:"lines"`
+}
+
+// ReceiptLine - Variance > 0 означает перепоставку, Variance < 0 - недопоставку
+type ReceiptLine struct {
+	GoodID   int `json:"good_id"`
+	Expected int `json:"expected"`
+	Received int `json:"received"`
+	Variance int `json:"variance"`
+}
+
+type OrderStatus string
+
+const (
+	OrderPicking OrderStatus = "picking"
+	OrderShipped OrderStatus = "shipped"
+)
+
+// Order - исходящий заказ: резервация, сборка по складам и отгрузка
+type Order struct {
+	ID            int         `json:"id"`
+	ReservationID int         `json:"reservation_id"`
+	Status        OrderStatus `json:"status"`
+	ShipmentID    *int        `json:"shipment_id,omitempty"`
+	CreatedAt     time.Time   `json:"created_at"`
+	ShippedAt     *time.Time  `json:"shipped_at,omitempty"`
+	PickLists     []PickList  `json:"pick_lists"`
+}
+
+type PickListStatus string
+
+const (
+	PickListOpen   PickListStatus = "open"
+	PickListPicked PickListStatus = "picked"
+)
+
+// PickList - лист сборки заказа на одном складе
+type PickList struct {
+	ID          int            `json:"id"`
+	OrderID     int            `json:"order_id"`
+	WarehouseID int            `json:"warehouse_id"`
+	Status      PickListStatus `json:"status"`
+	Lines       []PickLine     `json:"lines"`
+}
+
+type PickLine struct {
+	GoodID   int `json:"good_id"`
+	Quantity int `json:"quantity"`
+	Picked   int `json:"picked"`
+}
+
+// Pick - единицы товара, взятые из ячейки при сборке или отгрузке.
+// BinID 0 - ячейки товара на складе: сначала приемка, затем по id
+type Pick struct {
+	GoodID   int `json:"good_id"`
+	BinID    int `json:"bin_id,omitempty"`
+	Quantity int `json:"quantity"`
+}
+
+type ReturnDisposition string
+
+const (
+	DispositionRestock    ReturnDisposition = "restock"
+	DispositionQuarantine ReturnDisposition = "quarantine"
+	DispositionScrap      ReturnDisposition = "scrap"
+)
+
+// ReturnRequest - возврат по отгрузке или по заказу, товар принимается на WarehouseID
+type ReturnRequest struct {
+	ShipmentID  int
+	OrderID     int
+	WarehouseID int
+	Reason      string
+	Lines       []ReturnLine
+}
+
+// Return - возврат (RMA). count на складе увеличивают только строки с disposition restock
+type Return struct {
+	ID          int          `json:"id"`
+	ShipmentID  int          `json:"shipment_id"`
+	WarehouseID int          `json:"warehouse_id"`
+	Reason      string       `json:"reason"`
+	CreatedAt   time.Time    `json:"created_at"`
+	Lines       []ReturnLine `json:"lines"`
+}
+
+type ReturnLine struct {
+	GoodID      int               `json:"good_id"`
+	Quantity    int               `json:"quantity"`
+	Disposition ReturnDisposition `json:"disposition"`
+	Serials     []string          `json:"serials,omitempty"` // обязательны для товара с серийным учетом
+}
+
+type StockStatus string
+
+const (
+	StockSellable    StockStatus = "sellable"
+	StockQuarantined StockStatus = "quarantined"
+	StockDamaged     StockStatus = "damaged"
+)
+
+// StatusMoveRequest переводит единицы товара между статусами остатка на одном складе
+type StatusMoveRequest struct {
+	GoodID      int
+	WarehouseID int
+	From        StockStatus
+	To          StockStatus
+	Quantity    int
+	Reason      string
+	Serials     []string // пусто - серийные номера подбираются автоматически
+}
+
+// LotRef - партия, в которую принимается товар. Партия создается при первой приемке
+type LotRef struct {
+	Number    string    `json:"number"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Lot - партия товара на складе. Quantity входит в продаваемый count, Reserved - в reserved
+type Lot struct {
+	ID          int       `json:"id"`
+	GoodID      int       `json:"good_id"`
+	WarehouseID int       `json:"warehouse_id"`
+	Number      string    `json:"number"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Quantity    int       `json:"quantity"`
+	Reserved    int       `json:"reserved"`
+}
+
+type SerialStatus string
+
+const (
+	SerialInStock     SerialStatus = "in_stock"
+	SerialReserved    SerialStatus = "reserved"
+	SerialShipped     SerialStatus = "shipped"
+	SerialInTransit   SerialStatus = "in_transit"
+	SerialQuarantined SerialStatus = "quarantined"
+	SerialDamaged     SerialStatus = "damaged"
+	SerialWrittenOff  SerialStatus = "written_off"
+)
+
+// Serial - единица товара с серийным номером. WarehouseID пустой после отгрузки, списания и в пути
+type Serial struct {
+	ID            int           `json:"id"`
+	GoodID        int           `json:"good_id"`
+	Number        string        `json:"number"`
+	WarehouseID   *int          `json:"warehouse_id,omitempty"`
+	Status        SerialStatus  `json:"status"`
+	ReservationID *int          `json:"reservation_id,omitempty"`
+	ShipmentID    *int          `json:"shipment_id,omitempty"`
+	Events        []SerialEvent `json:"events"`
+}
+
+type SerialEventType string
+
+const (
+	SerialEventReceipt     SerialEventType = "receipt"
+	SerialEventReserve     SerialEventType = "reserve"
+	SerialEventRelease     SerialEventType = "release"
+	SerialEventShip        SerialEventType = "ship"
+	SerialEventTransferOut SerialEventType = "transfer_out"
+	SerialEventTransferIn  SerialEventType = "transfer_in"
+	SerialEventReturn      SerialEventType = "return"
+	SerialEventAdjust      SerialEventType = "adjust"
+	SerialEventStatus      SerialEventType = "status"
+)
+
+// SerialEvent - запись истории серийного номера
+type SerialEvent struct {
+	Type        SerialEventType `json:"type"`
+	WarehouseID *int            `json:"warehouse_id,omitempty"`
+	Reference   string          `json:"reference,omitempty"`
+	Actor       string          `json:"actor"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// Zone - зона склада, делится на ряды
+type Zone struct {
+	ID          int     `json:"id"`
+	WarehouseID int     `json:"warehouse_id"`
+	Code        string  `json:"code"`
+	Aisles      []Aisle `json:"aisles"`
+}
+
+// Aisle - ряд в зоне, делится на ячейки
+type Aisle struct {
+	ID     int    `json:"id"`
+	ZoneID int    `json:"zone_id"`
+	Code   string `json:"code"`
+	Bins   []Bin  `json:"bins"`
+}
+
+// Bin - ячейка хранения. Goods заполняется только при запросе ячейки.
+// В ячейку приемки попадают принятые единицы, пока их не разложат
+type Bin struct {
+	ID          int            `json:"id"`
+	AisleID     int            `json:"aisle_id"`
+	WarehouseID int            `json:"warehouse_id"`
+	Code        string         `json:"code"`
+	Receiving   bool           `json:"receiving,omitempty"`
+	Goods       []GoodQuantity `json:"goods,omitempty"`
+}
+
+// WarehouseLayout - структура склада вместе с зоной приемки
+type WarehouseLayout struct {
+	WarehouseID    int    `json:"warehouse_id"`
+	ReceivingBinID int    `json:"receiving_bin_id"`
+	Zones          []Zone `json:"zones"`
+}
+
+// BinMoveRequest перемещает единицы товара между ячейками одного склада
+type BinMoveRequest struct {
+	GoodID    int
+	FromBinID int
+	ToBinID   int
+	Quantity  int
+}
+
+// WarehouseCapacity - занятый объем склада: сумма size * (count + quarantined + damaged) по всем товарам.
+// Free пустой, если объем склада не ограничен
+type WarehouseCapacity struct {
+	WarehouseID int    `json:"warehouse_id"`
+	Name        string `json:"name"`
+	Capacity    int    `json:"capacity"`
+	Used        int    `json:"used"`
+	Free        *int   `json:"free,omitempty"`
+}
+
+// BoxType - тип коробки для упаковки, Capacity в единицах Good.Size
+type BoxType struct {
+	Name     string `json:"name"`
+	Capacity int    `json:"capacity"`
+}
+
+// PackingSuggestion - предложение упаковки резервации: коробки с содержимым и разбивка каждой строки по коробкам
+type PackingSuggestion struct {
+	ReservationID int           `json:"reservation_id"`
+	Boxes         []PackedBox   `json:"boxes"`
+	Lines         []PackingLine `json:"lines"`
+}
+
+// PackedBox - коробка, в которую упаковываются товары одного склада. Number сквозной по всей упаковке
+type PackedBox struct {
+	Number      int            `json:"number"`
+	BoxType     string         `json:"box_type"`
+	WarehouseID int            `json:"warehouse_id"`
+	Capacity    int            `json:"capacity"`
+	Used        int            `json:"used"`
+	Goods       []GoodQuantity `json:"goods"`
+}
+
+type PackingLine struct {
+	GoodID      int             `json:"good_id"`
+	WarehouseID int             `json:"warehouse_id"`
+	Quantity    int             `json:"quantity"`
+	Boxes       []BoxAssignment `json:"boxes"`
+}
+
+type BoxAssignment struct {
+	Box      int `json:"box"`
+	Quantity int `json:"quantity"`
+}
+
+// KitComponent - компонент комплекта и его количество в одном комплекте
+type KitComponent struct {
+	GoodID   int `json:"good_id"`
+	Quantity int `json:"quantity"`
+}
+
+// KitAvailability - число комплектов, которое можно зарезервировать на складе
+type KitAvailability struct {
+	WarehouseID   int    `json:"warehouse_id"`
+	WarehouseName string `json:"name"`
+	IsAvailable   bool   `json:"is_available"`
+	Available     int    `json:"available"`
+}
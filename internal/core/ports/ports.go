@@ -2,6 +2,7 @@ package ports
 
 import (
 	"context"
+	"time"
 	"warehouse/internal/core/domain"
 )
 
@@ -10,9 +11,55 @@ type GoodRepository interface {
 	CreateGood(ctx context.Context, good domain.Good) error
 	UpdateGood(ctx context.Context, good domain.Good) error
 	DeleteGood(ctx context.Context, id int) error
-	Reservation(ctx context.Context, pairs []domain.PairGoodWarehouse) (domain.MetaInfoReservation, error)
+	Reservation(ctx context.Context, req domain.ReservationRequest) (domain.MetaInfoReservation, error)
 	ReleaseReservation(ctx context.Context, pairs []domain.PairGoodWarehouse) (domain.MetaInfoReleaseReservation, error)
-	AddGoodOnWarehouse(ctx context.Context, goodID, warehouseID, count int) error
+	GetReservation(ctx context.Context, id int) (domain.Reservation, error)
+	ReleaseReservationByID(ctx context.Context, id int) (domain.MetaInfoReleaseReservation, error)
+	ExpireReservations(ctx context.Context, limit int, skip []int) (int, []int, error)
+	FulfilReservation(ctx context.Context, reservationID int, picks []domain.Pick) (domain.Shipment, error)
+	GetShipment(ctx context.Context, id int) (domain.Shipment, error)
+	AddGoodOnWarehouse(ctx context.Context, goodID, warehouseID, count int, lot *domain.LotRef, serials []string) error
+	GetFreeStock(ctx context.Context, goodID int) ([]domain.WarehouseStock, error)
+	GetStockMovements(ctx context.Context, filter domain.StockMovementFilter) ([]domain.StockMovement, error)
+	Transfer(ctx context.Context, req domain.TransferRequest) (domain.Transfer, error)
+	ReceiveTransfer(ctx context.Context, id int) (domain.Transfer, error)
+	GetTransfer(ctx context.Context, id int) (domain.Transfer, error)
+	AdjustStock(ctx context.Context, req domain.AdjustmentRequest) (domain.StockLevel, error)
+	OpenCountSession(ctx context.Context, warehouseID int) (domain.CountSession, error)
+	GetCountSession(ctx context.Context, id int) (domain.CountSession, error)
+	SubmitCount(ctx context.Context, id int, counted []domain.CountedGood) (domain.CountSession, error)
+	ApproveCountSession(ctx context.Context, id int, reason string) (domain.CountSession, error)
+	CancelCountSession(ctx context.Context, id int) (domain.CountSession, error)
+	SetStockThreshold(ctx context.Context, t domain.StockThreshold) error
+	CheckStockThresholds(ctx context.Context, keys []domain.StockKey) ([]domain.StockAlert, error)
+	GetStockAlerts(ctx context.Context, filter domain.StockAlertFilter) ([]domain.StockAlert, error)
+	CreateReceipt(ctx context.Context, warehouseID int, supplierRef string, lines []domain.GoodQuantity) (domain.Receipt, error)
+	GetReceipt(ctx context.Context, id int) (domain.Receipt, error)
+	ReceiveReceipt(ctx context.Context, id int, lines []domain.GoodQuantity) (domain.Receipt, error)
+	CloseReceipt(ctx context.Context, id int) (domain.Receipt, error)
+	CreateOrder(ctx context.Context, reservationID int) (domain.Order, error)
+	GetOrder(ctx context.Context, id int) (domain.Order, error)
+	PickGoods(ctx context.Context, pickListID int, picked []domain.Pick) (domain.Order, error)
+	CreateReturn(ctx context.Context, req domain.ReturnRequest) (domain.Return, error)
+	GetReturn(ctx context.Context, id int) (domain.Return, error)
+	MoveStockStatus(ctx context.Context, req domain.StatusMoveRequest) (domain.StockLevel, error)
+	GetExpiringLots(ctx context.Context, warehouseID int, before time.Time) ([]domain.Lot, error)
+	GetSerial(ctx context.Context, number string) (domain.Serial, error)
+	CreateZone(ctx context.Context, warehouseID int, code string) (domain.Zone, error)
+	CreateAisle(ctx context.Context, zoneID int, code string) (domain.Aisle, error)
+	CreateBin(ctx context.Context, aisleID int, code string) (domain.Bin, error)
+	GetWarehouseLayout(ctx context.Context, warehouseID int) (domain.WarehouseLayout, error)
+	GetBin(ctx context.Context, id int) (domain.Bin, error)
+	PutAway(ctx context.Context, binID, goodID, quantity int) (domain.Bin, error)
+	MoveBinStock(ctx context.Context, req domain.BinMoveRequest) ([]domain.Bin, error)
+	GetGoodSizes(ctx context.Context, goodIDs []int) (map[int]int, error)
+	GetKitComponents(ctx context.Context, kitID int) ([]domain.KitComponent, error)
+	SetKitComponents(ctx context.Context, kitID int, components []domain.KitComponent) error
+	GetStockDemand(ctx context.Context, goodID, warehouseID int, since time.Time) ([]domain.StockDemand, error)
+	ClaimIdempotencyKey(ctx context.Context, key, operation, requestHash string, window, lease time.Duration) ([]byte, string, error)
+	SaveIdempotencyResponse(ctx context.Context, claim domain.IdempotencyClaim, response []byte) error
+	ReleaseIdempotencyKey(ctx context.Context, claim domain.IdempotencyClaim) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context, window time.Duration) (int, error)
 	Close()
 }
 
@@ -22,5 +69,12 @@ type WarehouseRepository interface {
 	UpdateWarehouse(ctx context.Context, warehouse domain.Warehouse) error
 	DeleteWarehouse(ctx context.Context, id int) error
 	GetCountGoods(ctx context.Context, id int) (int, error)
+	SetWarehouseAvailability(ctx context.Context, id int, isAvailable bool) error
+	GetWarehouseCapacity(ctx context.Context, warehouseID int) ([]domain.WarehouseCapacity, error)
 	Close()
 }
+
+// Notifier доставляет алерты о низком остатке
+type Notifier interface {
+	Notify(ctx context.Context, alert domain.StockAlert) error
+}
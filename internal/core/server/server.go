@@ -5,14 +5,12 @@ import (
 	"net"
 	"net/http"
 	"warehouse/internal/adapters/handler"
-	"warehouse/internal/core/ports"
 	"warehouse/internal/core/services"
 )
 
-func NewServer(ctx context.Context, goodRepo ports.GoodRepository, warehouseRepo ports.WarehouseRepository, srvAddr string) *http.Server {
+func NewServer(ctx context.Context, goodService *services.GoodService, warehouseService *services.WarehouseService, srvAddr string) *http.Server {
 	router := http.NewServeMux()
 
-	goodService := services.NewGoodService(goodRepo)
 	goodHandler := handler.NewGoodHandler(*goodService)
 
 	router.HandleFunc("GET /getGood", goodHandler.GetGood)
@@ -20,10 +18,48 @@ func NewServer(ctx context.Context, goodRepo ports.GoodRepository, warehouseRepo
 	router.HandleFunc("PUT /updateGood", goodHandler.UpdateGood)
 	router.HandleFunc("DELETE /deleteGood", goodHandler.DeleteGood)
 	router.HandleFunc("PATCH /reserveGood", goodHandler.ReserveGood)
+	router.HandleFunc("PATCH /reserveGoodAuto", goodHandler.ReserveGoodAuto)
 	router.HandleFunc("PATCH /releaseReservationGood", goodHandler.ReleaseReservationGood)
+	router.HandleFunc("GET /getReservation", goodHandler.GetReservation)
+	router.HandleFunc("PATCH /fulfilReservation", goodHandler.FulfilReservation)
+	router.HandleFunc("GET /getShipment", goodHandler.GetShipment)
+	router.HandleFunc("GET /getStockMovements", goodHandler.GetStockMovements)
+	router.HandleFunc("POST /transferGood", goodHandler.TransferGood)
+	router.HandleFunc("PATCH /receiveTransfer", goodHandler.ReceiveTransfer)
+	router.HandleFunc("GET /getTransfer", goodHandler.GetTransfer)
+	router.HandleFunc("POST /adjustGood", goodHandler.AdjustGood)
+	router.HandleFunc("GET /getAdjustmentReasons", goodHandler.GetAdjustmentReasons)
+	router.HandleFunc("POST /openCountSession", goodHandler.OpenCountSession)
+	router.HandleFunc("GET /getCountSession", goodHandler.GetCountSession)
+	router.HandleFunc("PATCH /submitCount", goodHandler.SubmitCount)
+	router.HandleFunc("PATCH /approveCountSession", goodHandler.ApproveCountSession)
+	router.HandleFunc("PATCH /cancelCountSession", goodHandler.CancelCountSession)
+	router.HandleFunc("PUT /setStockThreshold", goodHandler.SetStockThreshold)
+	router.HandleFunc("GET /getStockAlerts", goodHandler.GetStockAlerts)
+	router.HandleFunc("GET /getReplenishment", goodHandler.GetReplenishment)
+	router.HandleFunc("POST /createReceipt", goodHandler.CreateReceipt)
+	router.HandleFunc("GET /getReceipt", goodHandler.GetReceipt)
+	router.HandleFunc("PATCH /receiveReceipt", goodHandler.ReceiveReceipt)
+	router.HandleFunc("PATCH /closeReceipt", goodHandler.CloseReceipt)
+	router.HandleFunc("POST /createOrder", goodHandler.CreateOrder)
+	router.HandleFunc("GET /getOrder", goodHandler.GetOrder)
+	router.HandleFunc("PATCH /pickGoods", goodHandler.PickGoods)
+	router.HandleFunc("POST /createReturn", goodHandler.CreateReturn)
+	router.HandleFunc("GET /getReturn", goodHandler.GetReturn)
+	router.HandleFunc("PATCH /moveStockStatus", goodHandler.MoveStockStatus)
+	router.HandleFunc("GET /getExpiringLots", goodHandler.GetExpiringLots)
+	router.HandleFunc("GET /getSerial", goodHandler.GetSerial)
+	router.HandleFunc("POST /createZone", goodHandler.CreateZone)
+	router.HandleFunc("POST /createAisle", goodHandler.CreateAisle)
+	router.HandleFunc("POST /createBin", goodHandler.CreateBin)
+	router.HandleFunc("GET /getWarehouseLayout", goodHandler.GetWarehouseLayout)
+	router.HandleFunc("GET /getBin", goodHandler.GetBin)
+	router.HandleFunc("PATCH /putAway", goodHandler.PutAway)
+	router.HandleFunc("PATCH /moveBinStock", goodHandler.MoveBinStock)
+	router.HandleFunc("GET /getPacking", goodHandler.GetPacking)
+	router.HandleFunc("PUT /setKitComponents", goodHandler.SetKitComponents)
 	router.HandleFunc("POST /addGoodOnWarehouse", goodHandler.AddGoodOnWarehouse)
 
-	warehouseService := services.NewWarehouseService(warehouseRepo)
 	warehouseHandler := handler.NewWarehouseHandler(*warehouseService)
 
 	router.HandleFunc("GET /getWarehouse", warehouseHandler.GetWarehouse)
@@ -31,10 +67,12 @@ func NewServer(ctx context.Context, goodRepo ports.GoodRepository, warehouseRepo
 	router.HandleFunc("PUT /updateWarehouse", warehouseHandler.UpdateWarehouse)
 	router.HandleFunc("DELETE /deleteWarehouse", warehouseHandler.DeleteWarehouse)
 	router.HandleFunc("GET /getCountGoods", warehouseHandler.GetCountGoods)
+	router.HandleFunc("PATCH /setWarehouseAvailability", warehouseHandler.SetAvailability)
+	router.HandleFunc("GET /getWarehouseCapacity", warehouseHandler.GetWarehouseCapacity)
 
 	return &http.Server{
 		Addr:    srvAddr,
-		Handler: router,
+		Handler: handler.ActorMiddleware(router),
 		BaseContext: func(_ net.Listener) context.Context {
 			return ctx
 		},
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"warehouse/internal/adapters/repository"
+	"warehouse/internal/core/domain"
+)
+
+var DefaultAdjustmentReasons = []string{"damage", "loss", "theft", "expiry", "found", "correction"}
+
+// AdjustStock меняет остаток на знаковую дельту с обязательной причиной из настроенного списка.
+// Изменение попадает в журнал движений вместе с причиной и автором.
+// Для товара с серийным учетом приходуемые номера обязательны, списываемые без номеров подбираются по порядку приемки
+func (gs *GoodService) AdjustStock(ctx context.Context, req domain.AdjustmentRequest) (domain.StockLevel, error) {
+	if !gs.validateID(req.GoodID) {
+		return domain.StockLevel{}, ErrGoodIDisNegative
+	}
+	if !gs.validateID(req.WarehouseID) {
+		return domain.StockLevel{}, ErrWarehouseIDisNegative
+	}
+	if req.Delta == 0 {
+		return domain.StockLevel{}, ErrDeltaIsZero
+	}
+	if !slices.Contains(gs.adjustmentReasons, req.Reason) {
+		return domain.StockLevel{}, ErrUnknownReason
+	}
+	if req.BinID != 0 && !gs.validateID(req.BinID) {
+		return domain.StockLevel{}, ErrBinIDisNegative
+	}
+	if err := validateSerials(req.Serials, max(req.Delta, -req.Delta)); err != nil {
+		return domain.StockLevel{}, err
+	}
+
+	level, err := gs.repo.AdjustStock(ctx, req)
+	if err != nil {
+		if errors.Is(err, repository.ErrIsNotExist) || errors.Is(err, repository.ErrFailedCheckGoodInWarehouse) {
+			return domain.StockLevel{}, ErrGoodWarehouseIsNotExist
+		}
+		if errors.Is(err, repository.ErrBelowReserved) {
+			return domain.StockLevel{}, ErrBelowReserved
+		}
+		if errors.Is(err, repository.ErrNotEnoughGoods) {
+			return domain.StockLevel{}, ErrNotEnoughGoods
+		}
+		if e := serialError(err); e != nil {
+			return domain.StockLevel{}, e
+		}
+		if e := stockBinError(err); e != nil {
+			return domain.StockLevel{}, e
+		}
+		if errors.Is(err, repository.ErrGoodIsKit) {
+			return domain.StockLevel{}, ErrGoodIsKit
+		}
+		return domain.StockLevel{}, fmt.Errorf("error adjust stock: %w", err)
+	}
+
+	gs.checkStockThresholds(ctx, []domain.StockKey{{GoodID: req.GoodID, WarehouseID: req.WarehouseID}})
+	return level, nil
+}
+
+func (gs *GoodService) AdjustmentReasons() []string {
+	return slices.Clone(gs.adjustmentReasons)
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"warehouse/internal/adapters/repository"
+	"warehouse/internal/core/domain"
+)
+
+// checkStockThresholds вызывается после уже закоммиченной операции,
+// поэтому ошибки проверки и доставки только логируются и не отменяют операцию
+func (gs *GoodService) checkStockThresholds(ctx context.Context, keys []domain.StockKey) {
+	if len(keys) == 0 {
+		return
+	}
+
+	alerts, err := gs.repo.CheckStockThresholds(ctx, keys)
+	if err != nil {
+		log.Printf("error check stock thresholds: %v", err)
+		return
+	}
+
+	if gs.notifier == nil {
+		return
+	}
+	for _, a := range alerts {
+		if err = gs.notifier.Notify(ctx, a); err != nil {
+			log.Printf("error notify stock alert %d: %v", a.ID, err)
+		}
+	}
+}
+
+func (gs *GoodService) SetStockThreshold(ctx context.Context, t domain.StockThreshold) error {
+	if !gs.validateID(t.GoodID) {
+		return ErrGoodIDisNegative
+	}
+	if !gs.validateID(t.WarehouseID) {
+		return ErrWarehouseIDisNegative
+	}
+	if t.ReorderPoint < 0 || t.TargetLevel < t.ReorderPoint {
+		return ErrInvalidThreshold
+	}
+
+	if err := gs.repo.SetStockThreshold(ctx, t); err != nil {
+		if errors.Is(err, repository.ErrIsNotExist) {
+			return ErrGoodWarehouseIsNotExist
+		}
+		return fmt.Errorf("error set stock threshold: %w", err)
+	}
+
+	// новый порог может сразу оказаться выше текущего остатка
+	gs.checkStockThresholds(ctx, []domain.StockKey{{GoodID: t.GoodID, WarehouseID: t.WarehouseID}})
+	return nil
+}
+
+func (gs *GoodService) GetStockAlerts(ctx context.Context, filter domain.StockAlertFilter) ([]domain.StockAlert, error) {
+	if filter.GoodID < 0 {
+		return nil, ErrGoodIDisNegative
+	}
+	if filter.WarehouseID < 0 {
+		return nil, ErrWarehouseIDisNegative
+	}
+
+	alerts, err := gs.repo.GetStockAlerts(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("error get stock alerts: %w", err)
+	}
+	return alerts, nil
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"sort"
+	"warehouse/internal/core/domain"
+)
+
+// встроенные стратегии выбора склада для резервации без склада
+const (
+	StrategyMostFreeStock = "most_free_stock"
+	StrategyFewestSplits  = "fewest_splits"
+	StrategyPriority      = "priority"
+)
+
+// AllocationStrategy раскладывает quantity единиц товара по складам.
+// stocks содержит только доступные склады с ненулевым свободным остатком.
+// Если товара не хватает, стратегия возвращает ErrNotEnoughGoods
+type AllocationStrategy interface {
+	Allocate(quantity int, stocks []domain.WarehouseStock) ([]domain.WarehouseStock, error)
+}
+
+// AllocationFunc позволяет использовать обычную функцию как AllocationStrategy
+type AllocationFunc func(quantity int, stocks []domain.WarehouseStock) ([]domain.WarehouseStock, error)
+
+func (f AllocationFunc) Allocate(quantity int, stocks []domain.WarehouseStock) ([]domain.WarehouseStock, error) {
+	return f(quantity, stocks)
+}
+
+// allocateGreedy берет со складов по очереди, пока не наберет quantity.
+// В ответе Free - сколько единиц взято с конкретного склада
+func allocateGreedy(quantity int, stocks []domain.WarehouseStock) ([]domain.WarehouseStock, error) {
+	res := make([]domain.WarehouseStock, 0)
+	for _, st := range stocks {
+		if quantity == 0 {
+			break
+		}
+		take := min(st.Free, quantity)
+		st.Free = take
+		res = append(res, st)
+		quantity -= take
+	}
+	if quantity > 0 {
+		return nil, ErrNotEnoughGoods
+	}
+	return res, nil
+}
+
+func sortedStocks(stocks []domain.WarehouseStock, less func(a, b domain.WarehouseStock) bool) []domain.WarehouseStock {
+	sorted := make([]domain.WarehouseStock, len(stocks))
+	copy(sorted, stocks)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return less(sorted[i], sorted[j])
+	})
+	return sorted
+}
+
+func byFreeDesc(a, b domain.WarehouseStock) bool {
+	if a.Free != b.Free {
+		return a.Free > b.Free
+	}
+	return a.WarehouseID < b.WarehouseID
+}
+
+// MostFreeStock берет товар со складов с наибольшим свободным остатком
+func MostFreeStock(quantity int, stocks []domain.WarehouseStock) ([]domain.WarehouseStock, error) {
+	return allocateGreedy(quantity, sortedStocks(stocks, byFreeDesc))
+}
+
+// FewestSplits старается уложиться в один склад, выбирая тот, где останется меньше всего.
+// Если одного склада не хватает, берет с самых полных, что дает минимум складов
+func FewestSplits(quantity int, stocks []domain.WarehouseStock) ([]domain.WarehouseStock, error) {
+	best := -1
+	for i, st := range stocks {
+		if st.Free < quantity {
+			continue
+		}
+		if best == -1 || st.Free < stocks[best].Free || (st.Free == stocks[best].Free && st.WarehouseID < stocks[best].WarehouseID) {
+			best = i
+		}
+	}
+	if best != -1 {
+		st := stocks[best]
+		st.Free = quantity
+		return []domain.WarehouseStock{st}, nil
+	}
+	return allocateGreedy(quantity, sortedStocks(stocks, byFreeDesc))
+}
+
+// Priority берет товар со складов в порядке убывания их приоритета
+func Priority(quantity int, stocks []domain.WarehouseStock) ([]domain.WarehouseStock, error) {
+	return allocateGreedy(quantity, sortedStocks(stocks, func(a, b domain.WarehouseStock) bool {
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		return a.WarehouseID < b.WarehouseID
+	}))
+}
+
+func defaultAllocationStrategies() map[string]AllocationStrategy {
+	return map[string]AllocationStrategy{
+		StrategyMostFreeStock: AllocationFunc(MostFreeStock),
+		StrategyFewestSplits:  AllocationFunc(FewestSplits),
+		StrategyPriority:      AllocationFunc(Priority),
+	}
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"warehouse/internal/core/domain"
+)
+
+func TestAllocationStrategies(t *testing.T) {
+	stocks := []domain.WarehouseStock{
+		{WarehouseID: 1, Free: 5, Priority: 1},
+		{WarehouseID: 2, Free: 10, Priority: 3},
+		{WarehouseID: 3, Free: 7, Priority: 3},
+		{WarehouseID: 4, Free: 10, Priority: 2},
+	}
+
+	tests := []struct {
+		name     string
+		strategy AllocationFunc
+		quantity int
+		stocks   []domain.WarehouseStock
+		want     []domain.WarehouseStock
+		wantErr  error
+	}{
+		{
+			name:     "most free stock takes from the fullest warehouse",
+			strategy: MostFreeStock,
+			quantity: 4,
+			stocks:   stocks,
+			want:     []domain.WarehouseStock{{WarehouseID: 2, Free: 4, Priority: 3}},
+		},
+		{
+			name:     "most free stock breaks ties by warehouse id",
+			strategy: MostFreeStock,
+			quantity: 15,
+			stocks:   stocks,
+			want: []domain.WarehouseStock{
+				{WarehouseID: 2, Free: 10, Priority: 3},
+				{WarehouseID: 4, Free: 5, Priority: 2},
+			},
+		},
+		{
+			name:     "most free stock takes everything",
+			strategy: MostFreeStock,
+			quantity: 32,
+			stocks:   stocks,
+			want: []domain.WarehouseStock{
+				{WarehouseID: 2, Free: 10, Priority: 3},
+				{WarehouseID: 4, Free: 10, Priority: 2},
+				{WarehouseID: 3, Free: 7, Priority: 3},
+				{WarehouseID: 1, Free: 5, Priority: 1},
+			},
+		},
+		{
+			name:     "most free stock not enough goods",
+			strategy: MostFreeStock,
+			quantity: 33,
+			stocks:   stocks,
+			wantErr:  ErrNotEnoughGoods,
+		},
+		{
+			name:     "fewest splits picks the smallest warehouse that fits",
+			strategy: FewestSplits,
+			quantity: 6,
+			stocks:   stocks,
+			want:     []domain.WarehouseStock{{WarehouseID: 3, Free: 6, Priority: 3}},
+		},
+		{
+			name:     "fewest splits exact single warehouse fit",
+			strategy: FewestSplits,
+			quantity: 5,
+			stocks:   stocks,
+			want:     []domain.WarehouseStock{{WarehouseID: 1, Free: 5, Priority: 1}},
+		},
+		{
+			name:     "fewest splits breaks ties by warehouse id",
+			strategy: FewestSplits,
+			quantity: 8,
+			stocks:   stocks,
+			want:     []domain.WarehouseStock{{WarehouseID: 2, Free: 8, Priority: 3}},
+		},
+		{
+			name:     "fewest splits falls back to the fullest warehouses",
+			strategy: FewestSplits,
+			quantity: 22,
+			stocks:   stocks,
+			want: []domain.WarehouseStock{
+				{WarehouseID: 2, Free: 10, Priority: 3},
+				{WarehouseID: 4, Free: 10, Priority: 2},
+				{WarehouseID: 3, Free: 2, Priority: 3},
+			},
+		},
+		{
+			name:     "fewest splits not enough goods",
+			strategy: FewestSplits,
+			quantity: 40,
+			stocks:   stocks,
+			wantErr:  ErrNotEnoughGoods,
+		},
+		{
+			name:     "priority takes from the highest priority",
+			strategy: Priority,
+			quantity: 9,
+			stocks:   stocks,
+			want:     []domain.WarehouseStock{{WarehouseID: 2, Free: 9, Priority: 3}},
+		},
+		{
+			name:     "priority breaks ties by warehouse id and splits",
+			strategy: Priority,
+			quantity: 20,
+			stocks:   stocks,
+			want: []domain.WarehouseStock{
+				{WarehouseID: 2, Free: 10, Priority: 3},
+				{WarehouseID: 3, Free: 7, Priority: 3},
+				{WarehouseID: 4, Free: 3, Priority: 2},
+			},
+		},
+		{
+			name:     "priority not enough goods",
+			strategy: Priority,
+			quantity: 33,
+			stocks:   stocks,
+			wantErr:  ErrNotEnoughGoods,
+		},
+		{
+			name:     "no stock",
+			strategy: MostFreeStock,
+			quantity: 1,
+			stocks:   []domain.WarehouseStock{},
+			wantErr:  ErrNotEnoughGoods,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := make([]domain.WarehouseStock, len(tt.stocks))
+			copy(before, tt.stocks)
+
+			got, err := tt.strategy.Allocate(tt.quantity, tt.stocks)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("allocation = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.stocks, before) {
+				t.Errorf("stocks changed: %v, want %v", tt.stocks, before)
+			}
+		})
+	}
+}
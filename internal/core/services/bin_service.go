@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"warehouse/internal/adapters/repository"
+	"warehouse/internal/core/domain"
+)
+
+// locationError переводит ошибки создания зоны, ряда или ячейки, parentNotFound - ошибка для отсутствующего родителя
+func locationError(err, parentNotFound error) error {
+	if errors.Is(err, repository.ErrIsNotExist) {
+		return parentNotFound
+	}
+	if errors.Is(err, repository.ErrIsExist) {
+		return ErrLocationIsExist
+	}
+	return nil
+}
+
+func binError(err error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrBinNotFound
+	}
+	if errors.Is(err, repository.ErrFailedCheckGoodInWarehouse) {
+		return ErrGoodWarehouseIsNotExist
+	}
+	if errors.Is(err, repository.ErrNotEnoughUnbinned) {
+		return ErrNotEnoughUnbinned
+	}
+	if errors.Is(err, repository.ErrNotEnoughInBin) {
+		return ErrNotEnoughInBin
+	}
+	if errors.Is(err, repository.ErrBinsInDifferentWarehouses) {
+		return ErrBinsInDifferentWarehouses
+	}
+	return nil
+}
+
+// stockBinError переводит ошибки ячейки, из которой снимается или в которую кладется остаток
+func stockBinError(err error) error {
+	if errors.Is(err, repository.ErrBinNotFound) {
+		return ErrBinNotFound
+	}
+	if errors.Is(err, repository.ErrBinIsNotInWarehouse) {
+		return ErrBinIsNotInWarehouse
+	}
+	if errors.Is(err, repository.ErrNotEnoughInBin) {
+		return ErrNotEnoughInBin
+	}
+	return nil
+}
+
+func (gs *GoodService) CreateZone(ctx context.Context, warehouseID int, code string) (domain.Zone, error) {
+	if !gs.validateID(warehouseID) {
+		return domain.Zone{}, ErrWarehouseIDisNegative
+	}
+	if code == "" {
+		return domain.Zone{}, ErrLocationCodeIsEmpty
+	}
+
+	z, err := gs.repo.CreateZone(ctx, warehouseID, code)
+	if err != nil {
+		if e := locationError(err, ErrWarehouseNotFound); e != nil {
+			return domain.Zone{}, e
+		}
+		return domain.Zone{}, fmt.Errorf("error create zone: %w", err)
+	}
+	return z, nil
+}
+
+func (gs *GoodService) CreateAisle(ctx context.Context, zoneID int, code string) (domain.Aisle, error) {
+	if !gs.validateID(zoneID) {
+		return domain.Aisle{}, ErrZoneIDisNegative
+	}
+	if code == "" {
+		return domain.Aisle{}, ErrLocationCodeIsEmpty
+	}
+
+	a, err := gs.repo.CreateAisle(ctx, zoneID, code)
+	if err != nil {
+		if e := locationError(err, ErrZoneNotFound); e != nil {
+			return domain.Aisle{}, e
+		}
+		return domain.Aisle{}, fmt.Errorf("error create aisle: %w", err)
+	}
+	return a, nil
+}
+
+func (gs *GoodService) CreateBin(ctx context.Context, aisleID int, code string) (domain.Bin, error) {
+	if !gs.validateID(aisleID) {
+		return domain.Bin{}, ErrAisleIDisNegative
+	}
+	if code == "" {
+		return domain.Bin{}, ErrLocationCodeIsEmpty
+	}
+
+	b, err := gs.repo.CreateBin(ctx, aisleID, code)
+	if err != nil {
+		if e := locationError(err, ErrAisleNotFound); e != nil {
+			return domain.Bin{}, e
+		}
+		return domain.Bin{}, fmt.Errorf("error create bin: %w", err)
+	}
+	return b, nil
+}
+
+// GetWarehouseLayout возвращает зоны, ряды и ячейки склада вместе с ячейкой приемки
+func (gs *GoodService) GetWarehouseLayout(ctx context.Context, warehouseID int) (domain.WarehouseLayout, error) {
+	if !gs.validateID(warehouseID) {
+		return domain.WarehouseLayout{}, ErrWarehouseIDisNegative
+	}
+
+	layout, err := gs.repo.GetWarehouseLayout(ctx, warehouseID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.WarehouseLayout{}, ErrWarehouseNotFound
+		}
+		return domain.WarehouseLayout{}, fmt.Errorf("error get warehouse layout: %w", err)
+	}
+	return layout, nil
+}
+
+func (gs *GoodService) GetBin(ctx context.Context, id int) (domain.Bin, error) {
+	if !gs.validateID(id) {
+		return domain.Bin{}, ErrBinIDisNegative
+	}
+
+	b, err := gs.repo.GetBin(ctx, id)
+	if err != nil {
+		if e := binError(err); e != nil {
+			return domain.Bin{}, e
+		}
+		return domain.Bin{}, fmt.Errorf("error get bin: %w", err)
+	}
+	return b, nil
+}
+
+// PutAway раскладывает в ячейку принятые, но еще не размещенные единицы товара
+func (gs *GoodService) PutAway(ctx context.Context, binID, goodID, quantity int) (domain.Bin, error) {
+	if !gs.validateID(binID) {
+		return domain.Bin{}, ErrBinIDisNegative
+	}
+	if !gs.validateID(goodID) {
+		return domain.Bin{}, ErrGoodIDisNegative
+	}
+	if !gs.validateID(quantity) {
+		return domain.Bin{}, ErrQuantityIsNegative
+	}
+
+	b, err := gs.repo.PutAway(ctx, binID, goodID, quantity)
+	if err != nil {
+		if e := binError(err); e != nil {
+			return domain.Bin{}, e
+		}
+		return domain.Bin{}, fmt.Errorf("error put away: %w", err)
+	}
+	return b, nil
+}
+
+// MoveBinStock перемещает единицы между ячейками одного склада
+func (gs *GoodService) MoveBinStock(ctx context.Context, req domain.BinMoveRequest) ([]domain.Bin, error) {
+	if !gs.validateID(req.FromBinID) || !gs.validateID(req.ToBinID) {
+		return nil, ErrBinIDisNegative
+	}
+	if req.FromBinID == req.ToBinID {
+		return nil, ErrSameBin
+	}
+	if !gs.validateID(req.GoodID) {
+		return nil, ErrGoodIDisNegative
+	}
+	if !gs.validateID(req.Quantity) {
+		return nil, ErrQuantityIsNegative
+	}
+
+	bins, err := gs.repo.MoveBinStock(ctx, req)
+	if err != nil {
+		if e := binError(err); e != nil {
+			return nil, e
+		}
+		return nil, fmt.Errorf("error move bin stock: %w", err)
+	}
+	return bins, nil
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"warehouse/internal/adapters/repository"
+	"warehouse/internal/core/domain"
+)
+
+// CountAdjustmentReason - причина корректировок, которые проводит утверждение инвентаризации
+const CountAdjustmentReason = "cycle_count"
+
+func countSessionError(err error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrCountSessionNotFound
+	}
+	if errors.Is(err, repository.ErrCountSessionIsNotOpen) {
+		return ErrCountSessionIsNotOpen
+	}
+	return err
+}
+
+// OpenCountSession открывает инвентаризацию склада, ожидаемые количества берутся из текущего count
+func (gs *GoodService) OpenCountSession(ctx context.Context, warehouseID int) (domain.CountSession, error) {
+	if !gs.validateID(warehouseID) {
+		return domain.CountSession{}, ErrWarehouseIDisNegative
+	}
+
+	s, err := gs.repo.OpenCountSession(ctx, warehouseID)
+	if err != nil {
+		if errors.Is(err, repository.ErrIsNotExist) {
+			return domain.CountSession{}, ErrWarehouseIsNotExist
+		}
+		if errors.Is(err, repository.ErrCountSessionIsExist) {
+			return domain.CountSession{}, ErrCountSessionIsExist
+		}
+		return domain.CountSession{}, fmt.Errorf("error open count session: %w", err)
+	}
+	return s, nil
+}
+
+func (gs *GoodService) GetCountSession(ctx context.Context, id int) (domain.CountSession, error) {
+	if !gs.validateID(id) {
+		return domain.CountSession{}, ErrCountSessionIDisNegative
+	}
+
+	s, err := gs.repo.GetCountSession(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.CountSession{}, ErrCountSessionNotFound
+		}
+		return domain.CountSession{}, fmt.Errorf("error get count session: %w", err)
+	}
+	return s, nil
+}
+
+func (gs *GoodService) SubmitCount(ctx context.Context, id int, counted []domain.CountedGood) (domain.CountSession, error) {
+	if !gs.validateID(id) {
+		return domain.CountSession{}, ErrCountSessionIDisNegative
+	}
+	if len(counted) == 0 {
+		return domain.CountSession{}, ErrCountedGoodsIsEmpty
+	}
+	for _, c := range counted {
+		if !gs.validateID(c.GoodID) {
+			return domain.CountSession{}, ErrGoodIDisNegative
+		}
+		if c.Counted < 0 {
+			return domain.CountSession{}, ErrCountIsNegative
+		}
+		if c.BinID != 0 && !gs.validateID(c.BinID) {
+			return domain.CountSession{}, ErrBinIDisNegative
+		}
+		// число номеров сверяется с расхождением при утверждении
+		if err := validateSerials(c.Serials, len(c.Serials)); err != nil {
+			return domain.CountSession{}, err
+		}
+	}
+
+	s, err := gs.repo.SubmitCount(ctx, id, counted)
+	if err != nil {
+		if errors.Is(err, repository.ErrIsNotExist) {
+			return domain.CountSession{}, ErrGoodIsNotExist
+		}
+		if e := stockBinError(err); e != nil {
+			return domain.CountSession{}, e
+		}
+		if errors.Is(err, repository.ErrGoodIsKit) {
+			return domain.CountSession{}, ErrGoodIsKit
+		}
+		err = countSessionError(err)
+		if errors.Is(err, ErrCountSessionNotFound) || errors.Is(err, ErrCountSessionIsNotOpen) {
+			return domain.CountSession{}, err
+		}
+		return domain.CountSession{}, fmt.Errorf("error submit count: %w", err)
+	}
+	return s, nil
+}
+
+// ApproveCountSession проводит расхождения корректировками. Если для какого-то товара
+// count стал бы меньше reserved, сессия остается открытой и ничего не проводится
+func (gs *GoodService) ApproveCountSession(ctx context.Context, id int) (domain.CountSession, error) {
+	if !gs.validateID(id) {
+		return domain.CountSession{}, ErrCountSessionIDisNegative
+	}
+
+	s, err := gs.repo.ApproveCountSession(ctx, id, CountAdjustmentReason)
+	if err != nil {
+		if errors.Is(err, repository.ErrBelowReserved) {
+			return domain.CountSession{}, fmt.Errorf("%w: %w", ErrBelowReserved, err)
+		}
+		if errors.Is(err, repository.ErrNotEnoughGoods) {
+			return domain.CountSession{}, fmt.Errorf("%w: %w", ErrNotEnoughGoods, err)
+		}
+		if e := serialError(err); e != nil {
+			return domain.CountSession{}, fmt.Errorf("%w: %w", e, err)
+		}
+		if e := stockBinError(err); e != nil {
+			return domain.CountSession{}, fmt.Errorf("%w: %w", e, err)
+		}
+		if errors.Is(err, repository.ErrGoodIsKit) {
+			return domain.CountSession{}, fmt.Errorf("%w: %w", ErrGoodIsKit, err)
+		}
+		err = countSessionError(err)
+		if errors.Is(err, ErrCountSessionNotFound) || errors.Is(err, ErrCountSessionIsNotOpen) {
+			return domain.CountSession{}, err
+		}
+		return domain.CountSession{}, fmt.Errorf("error approve count session: %w", err)
+	}
+
+	keys := make([]domain.StockKey, 0, len(s.Lines))
+	for _, l := range s.Lines {
+		if l.Variance != nil && *l.Variance != 0 {
+			keys = append(keys, domain.StockKey{GoodID: l.GoodID, WarehouseID: s.WarehouseID})
+		}
+	}
+	gs.checkStockThresholds(ctx, keys)
+	return s, nil
+}
+
+func (gs *GoodService) CancelCountSession(ctx context.Context, id int) (domain.CountSession, error) {
+	if !gs.validateID(id) {
+		return domain.CountSession{}, ErrCountSessionIDisNegative
+	}
+
+	s, err := gs.repo.CancelCountSession(ctx, id)
+	if err != nil {
+		err = countSessionError(err)
+		if errors.Is(err, ErrCountSessionNotFound) || errors.Is(err, ErrCountSessionIsNotOpen) {
+			return domain.CountSession{}, err
+		}
+		return domain.CountSession{}, fmt.Errorf("error cancel count session: %w", err)
+	}
+	return s, nil
+}
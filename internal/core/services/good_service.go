@@ -4,17 +4,70 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 	"warehouse/internal/adapters/repository"
 	"warehouse/internal/core/domain"
 	"warehouse/internal/core/ports"
 )
 
 type GoodService struct {
-	repo ports.GoodRepository
+	repo              ports.GoodRepository
+	idempotencyWindow time.Duration
+	idempotencyLease  time.Duration
+	adjustmentReasons []string
+	notifier          ports.Notifier
+	replenishment     domain.ReplenishmentParams
+	strategies        map[string]AllocationStrategy
+	boxTypes          []domain.BoxType
 }
 
-func NewGoodService(repo ports.GoodRepository) *GoodService {
-	return &GoodService{repo: repo}
+type GoodServiceConfig struct {
+	IdempotencyWindow time.Duration
+	IdempotencyLease  time.Duration
+	AdjustmentReasons []string
+	Notifier          ports.Notifier // nil - алерты только сохраняются
+	Replenishment     domain.ReplenishmentParams
+	BoxTypes          []domain.BoxType // пусто - DefaultBoxTypes
+}
+
+func NewGoodService(repo ports.GoodRepository, cfg GoodServiceConfig) *GoodService {
+	if cfg.IdempotencyWindow <= 0 {
+		cfg.IdempotencyWindow = DefaultIdempotencyWindow
+	}
+	if cfg.IdempotencyLease <= 0 {
+		cfg.IdempotencyLease = DefaultIdempotencyLease
+	}
+	if len(cfg.AdjustmentReasons) == 0 {
+		cfg.AdjustmentReasons = DefaultAdjustmentReasons
+	}
+	if cfg.Replenishment.WindowDays <= 0 {
+		cfg.Replenishment.WindowDays = DefaultReplenishmentParams.WindowDays
+	}
+	if cfg.Replenishment.LeadTimeDays <= 0 {
+		cfg.Replenishment.LeadTimeDays = DefaultReplenishmentParams.LeadTimeDays
+	}
+	if cfg.Replenishment.SafetyStockDays <= 0 {
+		cfg.Replenishment.SafetyStockDays = DefaultReplenishmentParams.SafetyStockDays
+	}
+	if len(cfg.BoxTypes) == 0 {
+		cfg.BoxTypes = DefaultBoxTypes
+	}
+	return &GoodService{
+		repo:              repo,
+		idempotencyWindow: cfg.IdempotencyWindow,
+		idempotencyLease:  cfg.IdempotencyLease,
+		adjustmentReasons: cfg.AdjustmentReasons,
+		notifier:          cfg.Notifier,
+		replenishment:     cfg.Replenishment,
+		strategies:        defaultAllocationStrategies(),
+		boxTypes:          sortBoxTypes(cfg.BoxTypes),
+	}
+}
+
+// RegisterAllocationStrategy добавляет или заменяет стратегию выбора склада.
+// Вызывать до начала обработки запросов
+func (gs *GoodService) RegisterAllocationStrategy(name string, strategy AllocationStrategy) {
+	gs.strategies[name] = strategy
 }
 
 func (gs *GoodService) validateID(id int) bool {
@@ -85,6 +138,7 @@ func (gs *GoodService) filterPairs(pairs []domain.PairGoodWarehouse) ([]domain.P
 	filteredPairs := make([]domain.PairGoodWarehouse, 0, len(pairs))
 	errPairs := make([]domain.PairGoodWarehouse, 0)
 	for _, pair := range pairs {
+		pair.Error = nil
 		if !gs.validateID(pair.GoodID) {
 			pair.Error = ErrGoodIDisNegative
 			errPairs = append(errPairs, pair)
@@ -97,32 +151,310 @@ func (gs *GoodService) filterPairs(pairs []domain.PairGoodWarehouse) ([]domain.P
 			continue
 		}
 
+		if !gs.validateID(pair.Quantity) {
+			pair.Error = ErrQuantityIsNegative
+			errPairs = append(errPairs, pair)
+			continue
+		}
+
+		if err := validateSerials(pair.Serials, pair.Quantity); err != nil {
+			pair.Error = err
+			errPairs = append(errPairs, pair)
+			continue
+		}
+
+		if pair.ReservationID < 0 {
+			pair.Error = ErrReservationIDisNegative
+			errPairs = append(errPairs, pair)
+			continue
+		}
+
 		filteredPairs = append(filteredPairs, pair)
 	}
 	return filteredPairs, errPairs
 }
 
-func (gs *GoodService) Reserve(ctx context.Context, pairs []domain.PairGoodWarehouse) (domain.MetaInfoReservation, error) {
-	filteredPairs, errPairs := gs.filterPairs(pairs)
-	res, err := gs.repo.Reservation(ctx, filteredPairs)
+// markPairErrors проставляет парам ошибки сервиса и их коды
+func (gs *GoodService) markPairErrors(pairs []domain.PairGoodWarehouse) {
+	for i := range pairs {
+		pairs[i].Error, pairs[i].ErrorCode = pairError(pairs[i].Error)
+	}
+}
+
+// withPairErrors дописывает к парам с ошибками из репозитория пары, не прошедшие проверку в сервисе,
+// и проставляет всем ошибки сервиса. Срез репозитория не меняется
+func (gs *GoodService) withPairErrors(repoPairs, errPairs []domain.PairGoodWarehouse) []domain.PairGoodWarehouse {
+	pairs := make([]domain.PairGoodWarehouse, 0, len(repoPairs)+len(errPairs))
+	pairs = append(append(pairs, repoPairs...), errPairs...)
+	gs.markPairErrors(pairs)
+	return pairs
+}
+
+func (gs *GoodService) Reserve(ctx context.Context, idempotencyKey string, req domain.ReservationRequest) (domain.MetaInfoReservation, error) {
+	return withIdempotency(ctx, gs, idempotencyKey, operationReserve, req, func(ctx context.Context) (domain.MetaInfoReservation, error) {
+		return gs.reserve(ctx, req)
+	})
+}
+
+func (gs *GoodService) reserve(ctx context.Context, req domain.ReservationRequest) (domain.MetaInfoReservation, error) {
+	if req.TTL < 0 {
+		return domain.MetaInfoReservation{}, ErrTTLIsNegative
+	}
+	filteredPairs, errPairs := gs.filterPairs(req.Pairs)
+	req.Pairs = filteredPairs
+	if req.Atomic {
+		// в атомарном режиме невалидные пары уходят в репозиторий вместе с ошибкой,
+		// чтобы отменить всю резервацию и при этом проверить остальные пары
+		req.Pairs = append(filteredPairs, errPairs...)
+		errPairs = nil
+	}
+	finish := func(res domain.MetaInfoReservation) domain.MetaInfoReservation {
+		res.ErrorReservation = gs.withPairErrors(res.ErrorReservation, errPairs)
+		return res
+	}
+	res, err := gs.repo.Reservation(respondWith(ctx, finish), req)
 	if err != nil {
 		return domain.MetaInfoReservation{}, fmt.Errorf("error reserve: %w", err)
 	}
-	res.ErrorReservation = append(res.ErrorReservation, errPairs...)
+	res = finish(res)
+	gs.checkStockThresholds(ctx, gs.reservedStockKeys(ctx, res.ReservedPairs))
 	return res, nil
 }
 
-func (gs *GoodService) ReleaseReservation(ctx context.Context, pairs []domain.PairGoodWarehouse) (domain.MetaInfoReleaseReservation, error) {
+// ReserveAuto резервирует товары без указания склада: склады выбирает стратегия,
+// после чего все получившиеся пары резервируются атомарно
+func (gs *GoodService) ReserveAuto(ctx context.Context, idempotencyKey string, req domain.AutoReservationRequest) (domain.MetaInfoReservation, error) {
+	return withIdempotency(ctx, gs, idempotencyKey, operationReserveAuto, req, func(ctx context.Context) (domain.MetaInfoReservation, error) {
+		return gs.reserveAuto(ctx, req)
+	})
+}
+
+func (gs *GoodService) reserveAuto(ctx context.Context, req domain.AutoReservationRequest) (domain.MetaInfoReservation, error) {
+	if req.TTL < 0 {
+		return domain.MetaInfoReservation{}, ErrTTLIsNegative
+	}
+	if req.Strategy == "" {
+		req.Strategy = StrategyMostFreeStock
+	}
+	strategy, ok := gs.strategies[req.Strategy]
+	if !ok {
+		return domain.MetaInfoReservation{}, ErrUnknownStrategy
+	}
+
+	pairs := make([]domain.PairGoodWarehouse, 0, len(req.Lines))
+	errPairs := make([]domain.PairGoodWarehouse, 0)
+	for _, line := range req.Lines {
+		pair := domain.PairGoodWarehouse{
+			GoodID:   line.GoodID,
+			Quantity: line.Quantity,
+		}
+		if !gs.validateID(line.GoodID) {
+			pair.Error = ErrGoodIDisNegative
+			errPairs = append(errPairs, pair)
+			continue
+		}
+		if !gs.validateID(line.Quantity) {
+			pair.Error = ErrQuantityIsNegative
+			errPairs = append(errPairs, pair)
+			continue
+		}
+
+		stocks, err := gs.repo.GetFreeStock(ctx, line.GoodID)
+		if err != nil {
+			return domain.MetaInfoReservation{}, fmt.Errorf("error get free stock: %w", err)
+		}
+		allocation, err := strategy.Allocate(line.Quantity, stocks)
+		if err != nil {
+			pair.Error = err
+			errPairs = append(errPairs, pair)
+			continue
+		}
+		for _, a := range allocation {
+			pairs = append(pairs, domain.PairGoodWarehouse{
+				GoodID:      line.GoodID,
+				WarehouseID: a.WarehouseID,
+				Quantity:    a.Free,
+			})
+		}
+	}
+
+	if len(errPairs) > 0 {
+		gs.markPairErrors(errPairs)
+		return domain.MetaInfoReservation{
+			ReservedPairs:    make([]domain.PairGoodWarehouse, 0),
+			ErrorReservation: errPairs,
+		}, nil
+	}
+
+	return gs.reserve(ctx, domain.ReservationRequest{
+		OrderRef: req.OrderRef,
+		TTL:      req.TTL,
+		Atomic:   true,
+		Pairs:    pairs,
+	})
+}
+
+func (gs *GoodService) ReleaseReservation(ctx context.Context, idempotencyKey string, pairs []domain.PairGoodWarehouse) (domain.MetaInfoReleaseReservation, error) {
+	return withIdempotency(ctx, gs, idempotencyKey, operationReleaseReservation, pairs, func(ctx context.Context) (domain.MetaInfoReleaseReservation, error) {
+		return gs.releaseReservation(ctx, pairs)
+	})
+}
+
+func (gs *GoodService) releaseReservation(ctx context.Context, pairs []domain.PairGoodWarehouse) (domain.MetaInfoReleaseReservation, error) {
 	filteredPairs, errPairs := gs.filterPairs(pairs)
-	res, err := gs.repo.ReleaseReservation(ctx, filteredPairs)
+	finish := func(res domain.MetaInfoReleaseReservation) domain.MetaInfoReleaseReservation {
+		res.ErrorRelease = gs.withPairErrors(res.ErrorRelease, errPairs)
+		return res
+	}
+	res, err := gs.repo.ReleaseReservation(respondWith(ctx, finish), filteredPairs)
 	if err != nil {
 		return domain.MetaInfoReleaseReservation{}, fmt.Errorf("error release reserve: %w", err)
 	}
-	res.ErrorRelease = append(res.ErrorRelease, errPairs...)
+	return finish(res), nil
+}
+
+func (gs *GoodService) GetReservation(ctx context.Context, id int) (domain.Reservation, error) {
+	if !gs.validateID(id) {
+		return domain.Reservation{}, ErrReservationIDisNegative
+	}
+	r, err := gs.repo.GetReservation(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.Reservation{}, ErrReservationNotFound
+		}
+		return domain.Reservation{}, fmt.Errorf("error get reservation: %w", err)
+	}
+	return r, nil
+}
+
+func (gs *GoodService) ReleaseReservationByID(ctx context.Context, idempotencyKey string, id int) (domain.MetaInfoReleaseReservation, error) {
+	return withIdempotency(ctx, gs, idempotencyKey, operationReleaseReservation, id, func(ctx context.Context) (domain.MetaInfoReleaseReservation, error) {
+		return gs.releaseReservationByID(ctx, id)
+	})
+}
+
+func (gs *GoodService) releaseReservationByID(ctx context.Context, id int) (domain.MetaInfoReleaseReservation, error) {
+	if !gs.validateID(id) {
+		return domain.MetaInfoReleaseReservation{}, ErrReservationIDisNegative
+	}
+	res, err := gs.repo.ReleaseReservationByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.MetaInfoReleaseReservation{}, ErrReservationNotFound
+		}
+		if errors.Is(err, repository.ErrReservationIsNotActive) {
+			return domain.MetaInfoReleaseReservation{}, ErrReservationIsNotActive
+		}
+		if errors.Is(err, repository.ErrReservationHasOrder) {
+			return domain.MetaInfoReleaseReservation{}, ErrReservationHasOrder
+		}
+		return domain.MetaInfoReleaseReservation{}, fmt.Errorf("error release reservation: %w", err)
+	}
 	return res, nil
 }
 
-func (gs *GoodService) AddGoodOnWarehouse(ctx context.Context, goodID, warehouseID, count int) error {
+// FulfilReservation отгружает резервацию. picks называют ячейки, из которых взяты единицы,
+// остальное снимается из ячеек товара на складе: сначала из приемки, затем по id ячейки
+func (gs *GoodService) FulfilReservation(ctx context.Context, reservationID int, picks []domain.Pick) (domain.Shipment, error) {
+	if !gs.validateID(reservationID) {
+		return domain.Shipment{}, ErrReservationIDisNegative
+	}
+	picks, err := gs.mergePicks(picks)
+	if err != nil {
+		return domain.Shipment{}, err
+	}
+
+	sh, err := gs.repo.FulfilReservation(ctx, reservationID, picks)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.Shipment{}, ErrReservationNotFound
+		}
+		if errors.Is(err, repository.ErrReservationIsNotActive) {
+			return domain.Shipment{}, ErrReservationIsNotActive
+		}
+		if errors.Is(err, repository.ErrReservationHasOrder) {
+			return domain.Shipment{}, ErrReservationHasOrder
+		}
+		if errors.Is(err, repository.ErrNotEnoughReserved) || errors.Is(err, repository.ErrFailedCheckGoodInWarehouse) {
+			return domain.Shipment{}, ErrNotEnoughReserved
+		}
+		if errors.Is(err, repository.ErrWarehouseIsUnavailable) {
+			return domain.Shipment{}, ErrWarehouseIsUnavailable
+		}
+		if errors.Is(err, repository.ErrPickExceedsQuantity) {
+			return domain.Shipment{}, ErrPickExceedsQuantity
+		}
+		if e := stockBinError(err); e != nil {
+			return domain.Shipment{}, e
+		}
+		return domain.Shipment{}, fmt.Errorf("error fulfil reservation: %w", err)
+	}
+
+	keys := make([]domain.StockKey, 0, len(sh.Lines))
+	for _, l := range sh.Lines {
+		keys = append(keys, domain.StockKey{GoodID: l.GoodID, WarehouseID: l.WarehouseID})
+	}
+	gs.checkStockThresholds(ctx, keys)
+	return sh, nil
+}
+
+func (gs *GoodService) GetShipment(ctx context.Context, id int) (domain.Shipment, error) {
+	if !gs.validateID(id) {
+		return domain.Shipment{}, ErrShipmentIDisNegative
+	}
+	sh, err := gs.repo.GetShipment(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.Shipment{}, ErrShipmentNotFound
+		}
+		return domain.Shipment{}, fmt.Errorf("error get shipment: %w", err)
+	}
+	return sh, nil
+}
+
+// expireBatchSize ограничивает число резерваций, снимаемых за один проход
+const expireBatchSize = 100
+
+// ExpireReservations снимает все просроченные резервации и возвращает их количество.
+// Резервации, которые не удалось снять, пропускаются до конца прохода, их ошибки возвращаются вместе
+func (gs *GoodService) ExpireReservations(ctx context.Context) (int, error) {
+	total := 0
+	failed := make([]int, 0)
+	errs := make([]error, 0)
+	for {
+		cnt, batchFailed, err := gs.repo.ExpireReservations(ctx, expireBatchSize, failed)
+		total += cnt
+		failed = append(failed, batchFailed...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if cnt+len(batchFailed) < expireBatchSize {
+			break
+		}
+	}
+	if len(errs) > 0 {
+		return total, fmt.Errorf("error expire reservations: %w", errors.Join(errs...))
+	}
+	return total, nil
+}
+
+// AddGoodOnWarehouse принимает товар на склад. Если передана партия, единицы учитываются в ней.
+// Для товара с серийным учетом serials обязательны, по одному на единицу
+func (gs *GoodService) AddGoodOnWarehouse(ctx context.Context, idempotencyKey string, goodID, warehouseID, count int, lot *domain.LotRef, serials []string) error {
+	request := struct {
+		GoodID      int            `json:"good_id"`
+		WarehouseID int            `json:"warehouse_id"`
+		Count       int            `json:"count"`
+		Lot         *domain.LotRef `json:"lot,omitempty"`
+		Serials     []string       `json:"serials,omitempty"`
+	}{goodID, warehouseID, count, lot, serials}
+	_, err := withIdempotency(ctx, gs, idempotencyKey, operationAddGoodOnWarehouse, request, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, gs.addGoodOnWarehouse(ctx, goodID, warehouseID, count, lot, serials)
+	})
+	return err
+}
+
+func (gs *GoodService) addGoodOnWarehouse(ctx context.Context, goodID, warehouseID, count int, lot *domain.LotRef, serials []string) error {
 	if !gs.validateID(goodID) {
 		return ErrGoodIDisNegative
 	}
@@ -135,8 +467,34 @@ func (gs *GoodService) AddGoodOnWarehouse(ctx context.Context, goodID, warehouse
 		return ErrCountIsNegative
 	}
 
-	if err := gs.repo.AddGoodOnWarehouse(ctx, goodID, warehouseID, count); err != nil {
+	if err := validateLot(lot); err != nil {
+		return err
+	}
+
+	if err := validateSerials(serials, count); err != nil {
 		return err
 	}
+
+	if err := gs.repo.AddGoodOnWarehouse(ctx, goodID, warehouseID, count, lot, serials); err != nil {
+		if errors.Is(err, repository.ErrIsNotExist) {
+			return ErrGoodWarehouseIsNotExist
+		}
+		if errors.Is(err, repository.ErrWarehouseIsUnavailable) {
+			return ErrWarehouseIsUnavailable
+		}
+		if errors.Is(err, repository.ErrLotExpiryMismatch) {
+			return ErrLotExpiryMismatch
+		}
+		if errors.Is(err, repository.ErrCapacityExceeded) {
+			return ErrCapacityExceeded
+		}
+		if errors.Is(err, repository.ErrGoodIsKit) {
+			return ErrGoodIsKit
+		}
+		if e := serialError(err); e != nil {
+			return e
+		}
+		return fmt.Errorf("error add good on warehouse: %w", err)
+	}
 	return nil
 }
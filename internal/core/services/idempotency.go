@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+	"warehouse/internal/adapters/repository"
+	"warehouse/internal/core/domain"
+)
+
+const DefaultIdempotencyWindow = 24 * time.Hour
+
+// DefaultIdempotencyLease - сколько ключ без ответа считается занятым выполняемым запросом
+const DefaultIdempotencyLease = 30 * time.Second
+
+// операции, для которых поддерживается Idempotency-Key
+const (
+	operationReserve            = "reserve"
+	operationReserveAuto        = "reserve_auto"
+	operationReleaseReservation = "release_reservation"
+	operationAddGoodOnWarehouse = "add_good_on_warehouse"
+)
+
+func requestHash(request any) (string, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// withIdempotency выполняет do не больше одного раза на ключ в пределах окна.
+// Повтор с тем же ключом возвращает сохраненный ответ первого успешного вызова.
+// Занятый ключ передается в do через контекст, и репозиторий сохраняет ответ
+// в транзакции операции. Если do вернул ошибку, ключ освобождается и запрос можно повторить.
+// Ключ, брошенный без ответа, освобождается сам по истечении аренды
+func withIdempotency[T any](ctx context.Context, gs *GoodService, key, operation string, request any, do func(ctx context.Context) (T, error)) (T, error) {
+	var res T
+	if key == "" {
+		return do(ctx)
+	}
+
+	hash, err := requestHash(request)
+	if err != nil {
+		return res, fmt.Errorf("error hash request: %w", err)
+	}
+
+	stored, token, err := gs.repo.ClaimIdempotencyKey(ctx, key, operation, hash, gs.idempotencyWindow, gs.idempotencyLease)
+	if err != nil {
+		if errors.Is(err, repository.ErrIdempotencyKeyInProgress) {
+			return res, ErrIdempotencyKeyInProgress
+		}
+		if errors.Is(err, repository.ErrIdempotencyKeyMismatch) {
+			return res, ErrIdempotencyKeyMismatch
+		}
+		return res, fmt.Errorf("error claim idempotency key: %w", err)
+	}
+
+	if token == "" {
+		if err = json.Unmarshal(stored, &res); err != nil {
+			return res, fmt.Errorf("error decode stored response: %w", err)
+		}
+		return res, nil
+	}
+
+	claim := domain.IdempotencyClaim{Key: key, Operation: operation, Token: token}
+	// ключ освобождается и ответ сохраняется, даже если клиент уже отключился
+	saveCtx := context.WithoutCancel(ctx)
+	res, err = do(domain.ContextWithIdempotencyClaim(ctx, claim))
+	if err != nil {
+		if errors.Is(err, repository.ErrIdempotencyKeyInProgress) {
+			// аренда истекла, и ключ занял другой запрос, операция откатилась
+			return res, ErrIdempotencyKeyInProgress
+		}
+		if errRelease := gs.repo.ReleaseIdempotencyKey(saveCtx, claim); errRelease != nil {
+			return res, errors.Join(err, errRelease)
+		}
+		return res, err
+	}
+
+	// операция, которая ничего не зафиксировала, ответ в транзакции не сохраняла
+	data, err := json.Marshal(res)
+	if err != nil {
+		return res, fmt.Errorf("error encode response: %w", err)
+	}
+	if err = gs.repo.SaveIdempotencyResponse(saveCtx, claim, data); err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// respondWith сообщает репозиторию, как из его результата получить ответ сервиса,
+// чтобы в транзакции операции сохранился тот же ответ, что вернет сервис
+func respondWith[R, T any](ctx context.Context, finish func(R) T) context.Context {
+	claim, ok := domain.IdempotencyClaimFromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	claim.Response = func(result any) ([]byte, error) {
+		r, ok := result.(R)
+		if !ok {
+			return nil, fmt.Errorf("unexpected result %T", result)
+		}
+		return json.Marshal(finish(r))
+	}
+	return domain.ContextWithIdempotencyClaim(ctx, claim)
+}
+
+// DeleteExpiredIdempotencyKeys удаляет ключи, вышедшие за окно идемпотентности
+func (gs *GoodService) DeleteExpiredIdempotencyKeys(ctx context.Context) (int, error) {
+	cnt, err := gs.repo.DeleteExpiredIdempotencyKeys(ctx, gs.idempotencyWindow)
+	if err != nil {
+		return 0, fmt.Errorf("error delete expired idempotency keys: %w", err)
+	}
+	return cnt, nil
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"warehouse/internal/adapters/repository"
+	"warehouse/internal/core/domain"
+)
+
+func (gs *GoodService) validateKitComponents(kitID int, components []domain.KitComponent) error {
+	seen := make(map[int]struct{}, len(components))
+	for _, c := range components {
+		if !gs.validateID(c.GoodID) {
+			return ErrGoodIDisNegative
+		}
+		if !gs.validateID(c.Quantity) {
+			return ErrQuantityIsNegative
+		}
+		if c.GoodID == kitID {
+			return ErrKitContainsItself
+		}
+		if _, ok := seen[c.GoodID]; ok {
+			return ErrKitComponentsIsDuplicated
+		}
+		seen[c.GoodID] = struct{}{}
+	}
+	return nil
+}
+
+// SetKitComponents задает состав комплекта и возвращает комплект с рассчитанной доступностью.
+// Пустой состав превращает комплект обратно в обычный товар
+func (gs *GoodService) SetKitComponents(ctx context.Context, kitID int, components []domain.KitComponent) (domain.Good, error) {
+	if !gs.validateID(kitID) {
+		return domain.Good{}, ErrGoodIDisNegative
+	}
+	if err := gs.validateKitComponents(kitID, components); err != nil {
+		return domain.Good{}, err
+	}
+
+	if err := gs.repo.SetKitComponents(ctx, kitID, components); err != nil {
+		if errors.Is(err, repository.ErrIsNotExist) {
+			return domain.Good{}, ErrGoodNotFound
+		}
+		if errors.Is(err, repository.ErrKitHasStock) {
+			return domain.Good{}, ErrKitHasStock
+		}
+		if errors.Is(err, repository.ErrNestedKit) {
+			return domain.Good{}, ErrNestedKit
+		}
+		return domain.Good{}, fmt.Errorf("error set kit components: %w", err)
+	}
+	return gs.GetGood(ctx, kitID)
+}
+
+// reservedStockKeys возвращает строки остатка, затронутые резервацией: для комплекта - строки его компонентов.
+// Используется только для проверки порогов, поэтому ошибки логируются
+func (gs *GoodService) reservedStockKeys(ctx context.Context, pairs []domain.PairGoodWarehouse) []domain.StockKey {
+	keys := make([]domain.StockKey, 0, len(pairs))
+	for _, p := range pairs {
+		components, err := gs.repo.GetKitComponents(ctx, p.GoodID)
+		if err != nil {
+			log.Printf("error get components of kit %d: %v", p.GoodID, err)
+			continue
+		}
+		if len(components) == 0 {
+			keys = append(keys, domain.StockKey{GoodID: p.GoodID, WarehouseID: p.WarehouseID})
+			continue
+		}
+		for _, c := range components {
+			keys = append(keys, domain.StockKey{GoodID: c.GoodID, WarehouseID: p.WarehouseID})
+		}
+	}
+	return keys
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"warehouse/internal/core/domain"
+)
+
+// validateLot проверяет партию, nil - товар принимается вне партий
+func validateLot(lot *domain.LotRef) error {
+	if lot == nil {
+		return nil
+	}
+	if lot.Number == "" {
+		return ErrLotNumberIsEmpty
+	}
+	if lot.ExpiresAt.IsZero() {
+		return ErrLotExpiryIsEmpty
+	}
+	return nil
+}
+
+func lotNumber(lot *domain.LotRef) string {
+	if lot == nil {
+		return ""
+	}
+	return lot.Number
+}
+
+// GetExpiringLots возвращает партии, срок годности которых истекает в ближайшие days дней, включая просроченные
+func (gs *GoodService) GetExpiringLots(ctx context.Context, warehouseID, days int) ([]domain.Lot, error) {
+	if warehouseID < 0 {
+		return nil, ErrWarehouseIDisNegative
+	}
+	if days < 0 {
+		return nil, ErrDaysIsNegative
+	}
+
+	before := time.Now().Truncate(24*time.Hour).AddDate(0, 0, days)
+	lots, err := gs.repo.GetExpiringLots(ctx, warehouseID, before)
+	if err != nil {
+		return nil, fmt.Errorf("error get expiring lots: %w", err)
+	}
+	return lots, nil
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"warehouse/internal/core/domain"
+)
+
+const (
+	defaultMovementsLimit = 1000
+	maxMovementsLimit     = 10000
+)
+
+// GetStockMovements возвращает журнал движений по товару, складу и интервалу времени [From, To)
+func (gs *GoodService) GetStockMovements(ctx context.Context, filter domain.StockMovementFilter) ([]domain.StockMovement, error) {
+	if filter.GoodID < 0 {
+		return nil, ErrGoodIDisNegative
+	}
+	if filter.WarehouseID < 0 {
+		return nil, ErrWarehouseIDisNegative
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, ErrInvalidTimeRange
+	}
+	if filter.Limit < 0 || filter.Limit > maxMovementsLimit {
+		return nil, ErrInvalidLimit
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultMovementsLimit
+	}
+
+	movements, err := gs.repo.GetStockMovements(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("error get stock movements: %w", err)
+	}
+	return movements, nil
+}
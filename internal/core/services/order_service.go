@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"warehouse/internal/adapters/repository"
+	"warehouse/internal/core/domain"
+)
+
+// CreateOrder создает исходящий заказ по активной резервации с листами сборки по складам
+func (gs *GoodService) CreateOrder(ctx context.Context, reservationID int) (domain.Order, error) {
+	if !gs.validateID(reservationID) {
+		return domain.Order{}, ErrReservationIDisNegative
+	}
+
+	o, err := gs.repo.CreateOrder(ctx, reservationID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.Order{}, ErrReservationNotFound
+		}
+		if errors.Is(err, repository.ErrReservationIsNotActive) {
+			return domain.Order{}, ErrReservationIsNotActive
+		}
+		if errors.Is(err, repository.ErrOrderIsExist) {
+			return domain.Order{}, ErrOrderIsExist
+		}
+		return domain.Order{}, fmt.Errorf("error create order: %w", err)
+	}
+	return o, nil
+}
+
+func (gs *GoodService) GetOrder(ctx context.Context, id int) (domain.Order, error) {
+	if !gs.validateID(id) {
+		return domain.Order{}, ErrOrderIDisNegative
+	}
+
+	o, err := gs.repo.GetOrder(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.Order{}, ErrOrderNotFound
+		}
+		return domain.Order{}, fmt.Errorf("error get order: %w", err)
+	}
+	return o, nil
+}
+
+// mergePicks проверяет взятые единицы и складывает повторы одного товара из одной ячейки
+func (gs *GoodService) mergePicks(picks []domain.Pick) ([]domain.Pick, error) {
+	type key struct {
+		goodID int
+		binID  int
+	}
+	merged := make([]domain.Pick, 0, len(picks))
+	index := make(map[key]int, len(picks))
+	for _, p := range picks {
+		if !gs.validateID(p.GoodID) {
+			return nil, ErrGoodIDisNegative
+		}
+		if p.BinID != 0 && !gs.validateID(p.BinID) {
+			return nil, ErrBinIDisNegative
+		}
+		if !gs.validateID(p.Quantity) {
+			return nil, ErrQuantityIsNegative
+		}
+		k := key{goodID: p.GoodID, binID: p.BinID}
+		if i, ok := index[k]; ok {
+			merged[i].Quantity += p.Quantity
+			continue
+		}
+		index[k] = len(merged)
+		merged = append(merged, p)
+	}
+	return merged, nil
+}
+
+// PickGoods отмечает собранные товары и ячейки, из которых они взяты. Сборка последнего листа отгружает заказ
+func (gs *GoodService) PickGoods(ctx context.Context, pickListID int, picked []domain.Pick) (domain.Order, error) {
+	if !gs.validateID(pickListID) {
+		return domain.Order{}, ErrPickListIDisNegative
+	}
+	if len(picked) == 0 {
+		return domain.Order{}, ErrPickedGoodsIsEmpty
+	}
+	picked, err := gs.mergePicks(picked)
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	o, err := gs.repo.PickGoods(ctx, pickListID, picked)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.Order{}, ErrPickListNotFound
+		}
+		if errors.Is(err, repository.ErrOrderIsNotPicking) {
+			return domain.Order{}, ErrOrderIsNotPicking
+		}
+		if errors.Is(err, repository.ErrFailedCheckGoodInWarehouse) {
+			return domain.Order{}, ErrGoodIsNotInPickList
+		}
+		if errors.Is(err, repository.ErrPickExceedsQuantity) {
+			return domain.Order{}, ErrPickExceedsQuantity
+		}
+		if errors.Is(err, repository.ErrReservationIsNotActive) {
+			return domain.Order{}, ErrReservationIsNotActive
+		}
+		if errors.Is(err, repository.ErrNotEnoughReserved) {
+			return domain.Order{}, ErrNotEnoughReserved
+		}
+		if errors.Is(err, repository.ErrWarehouseIsUnavailable) {
+			return domain.Order{}, ErrWarehouseIsUnavailable
+		}
+		if e := stockBinError(err); e != nil {
+			return domain.Order{}, e
+		}
+		return domain.Order{}, fmt.Errorf("error pick goods: %w", err)
+	}
+
+	if o.Status == domain.OrderShipped {
+		keys := make([]domain.StockKey, 0)
+		for _, pl := range o.PickLists {
+			for _, l := range pl.Lines {
+				keys = append(keys, domain.StockKey{GoodID: l.GoodID, WarehouseID: pl.WarehouseID})
+			}
+		}
+		gs.checkStockThresholds(ctx, keys)
+	}
+	return o, nil
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"warehouse/internal/core/domain"
+)
+
+var DefaultBoxTypes = []domain.BoxType{
+	{Name: "S", Capacity: 10},
+	{Name: "M", Capacity: 30},
+	{Name: "L", Capacity: 60},
+}
+
+// sortBoxTypes отбрасывает типы без объема и сортирует остальные по возрастанию объема
+func sortBoxTypes(types []domain.BoxType) []domain.BoxType {
+	sorted := make([]domain.BoxType, 0, len(types))
+	for _, t := range types {
+		if t.Capacity > 0 {
+			sorted = append(sorted, t)
+		}
+	}
+	if len(sorted) == 0 {
+		sorted = append(sorted, DefaultBoxTypes...)
+	}
+	slices.SortStableFunc(sorted, func(a, b domain.BoxType) int {
+		return a.Capacity - b.Capacity
+	})
+	return sorted
+}
+
+// SuggestPacking предлагает упаковку резервации или заказа в настроенные коробки.
+// Товары разных складов в одну коробку не попадают
+func (gs *GoodService) SuggestPacking(ctx context.Context, reservationID, orderID int) (domain.PackingSuggestion, error) {
+	if (reservationID == 0) == (orderID == 0) {
+		return domain.PackingSuggestion{}, ErrPackingSourceIsInvalid
+	}
+	if reservationID < 0 {
+		return domain.PackingSuggestion{}, ErrReservationIDisNegative
+	}
+	if orderID < 0 {
+		return domain.PackingSuggestion{}, ErrOrderIDisNegative
+	}
+
+	if orderID > 0 {
+		o, err := gs.GetOrder(ctx, orderID)
+		if err != nil {
+			return domain.PackingSuggestion{}, err
+		}
+		reservationID = o.ReservationID
+	}
+
+	r, err := gs.GetReservation(ctx, reservationID)
+	if err != nil {
+		return domain.PackingSuggestion{}, err
+	}
+	if r.Status == domain.ReservationReleased || r.Status == domain.ReservationExpired {
+		return domain.PackingSuggestion{}, ErrReservationIsNotActive
+	}
+
+	goodIDs := make([]int, 0, len(r.Lines))
+	for _, l := range r.Lines {
+		goodIDs = append(goodIDs, l.GoodID)
+	}
+	sizes, err := gs.repo.GetGoodSizes(ctx, goodIDs)
+	if err != nil {
+		return domain.PackingSuggestion{}, fmt.Errorf("error get good sizes: %w", err)
+	}
+
+	boxes, lines, err := packLines(r.Lines, sizes, gs.boxTypes)
+	if err != nil {
+		return domain.PackingSuggestion{}, err
+	}
+	return domain.PackingSuggestion{ReservationID: reservationID, Boxes: boxes, Lines: lines}, nil
+}
+
+// packLines раскладывает строки по коробкам first fit decreasing: единицы крупных товаров идут первыми
+// и кладутся в первую коробку склада, где есть место, новые коробки берутся наибольшего типа.
+// В конце каждая коробка заменяется наименьшим типом, в который помещается ее содержимое
+func packLines(reservationLines []domain.ReservationLine, sizes map[int]int, boxTypes []domain.BoxType) ([]domain.PackedBox, []domain.PackingLine, error) {
+	// у резервации может быть несколько строк одного товара на складе (доборы, комплекты с общим компонентом),
+	// а в упаковке товар склада - одна строка
+	type key struct {
+		goodID      int
+		warehouseID int
+	}
+	index := make(map[key]int, len(reservationLines))
+	lines := make([]domain.ReservationLine, 0, len(reservationLines))
+	for _, l := range reservationLines {
+		k := key{goodID: l.GoodID, warehouseID: l.WarehouseID}
+		if i, ok := index[k]; ok {
+			lines[i].Quantity += l.Quantity
+			continue
+		}
+		index[k] = len(lines)
+		lines = append(lines, l)
+	}
+	for _, l := range lines {
+		if _, ok := sizes[l.GoodID]; !ok {
+			return nil, nil, fmt.Errorf("%w: %d", ErrGoodNotFound, l.GoodID)
+		}
+	}
+	slices.SortStableFunc(lines, func(a, b domain.ReservationLine) int {
+		if a.WarehouseID != b.WarehouseID {
+			return a.WarehouseID - b.WarehouseID
+		}
+		if sizes[a.GoodID] != sizes[b.GoodID] {
+			return sizes[b.GoodID] - sizes[a.GoodID]
+		}
+		return a.GoodID - b.GoodID
+	})
+
+	largest := boxTypes[len(boxTypes)-1].Capacity
+	boxes := make([]domain.PackedBox, 0)
+	packingLines := make([]domain.PackingLine, 0, len(lines))
+	warehouseStart := 0
+	put := func(i, quantity, size int, pl *domain.PackingLine) {
+		b := &boxes[i]
+		b.Used += quantity * size
+		if n := len(b.Goods); n > 0 && b.Goods[n-1].GoodID == pl.GoodID {
+			b.Goods[n-1].Quantity += quantity
+		} else {
+			b.Goods = append(b.Goods, domain.GoodQuantity{GoodID: pl.GoodID, Quantity: quantity})
+		}
+		if n := len(pl.Boxes); n > 0 && pl.Boxes[n-1].Box == b.Number {
+			pl.Boxes[n-1].Quantity += quantity
+		} else {
+			pl.Boxes = append(pl.Boxes, domain.BoxAssignment{Box: b.Number, Quantity: quantity})
+		}
+	}
+
+	for i, l := range lines {
+		size := sizes[l.GoodID]
+		if size > largest {
+			return nil, nil, fmt.Errorf("%w: %d", ErrGoodDoesNotFitBox, l.GoodID)
+		}
+		if i > 0 && lines[i-1].WarehouseID != l.WarehouseID {
+			warehouseStart = len(boxes)
+		}
+
+		pl := domain.PackingLine{GoodID: l.GoodID, WarehouseID: l.WarehouseID, Quantity: l.Quantity, Boxes: make([]domain.BoxAssignment, 0)}
+		rest := l.Quantity
+		for j := warehouseStart; j < len(boxes) && rest > 0; j++ {
+			if q := min(rest, (largest-boxes[j].Used)/size); q > 0 {
+				put(j, q, size, &pl)
+				rest -= q
+			}
+		}
+		for rest > 0 {
+			boxes = append(boxes, domain.PackedBox{Number: len(boxes) + 1, WarehouseID: l.WarehouseID, Goods: make([]domain.GoodQuantity, 0)})
+			q := min(rest, largest/size)
+			put(len(boxes)-1, q, size, &pl)
+			rest -= q
+		}
+		packingLines = append(packingLines, pl)
+	}
+
+	for i := range boxes {
+		for _, t := range boxTypes {
+			if t.Capacity >= boxes[i].Used {
+				boxes[i].BoxType = t.Name
+				boxes[i].Capacity = t.Capacity
+				break
+			}
+		}
+	}
+	return boxes, packingLines, nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"warehouse/internal/core/domain"
+)
+
+func TestPackLines(t *testing.T) {
+	tests := []struct {
+		name      string
+		lines     []domain.ReservationLine
+		sizes     map[int]int
+		wantBoxes []domain.PackedBox
+		wantLines []domain.PackingLine
+		wantErr   error
+	}{
+		{
+			name:    "good larger than the largest box",
+			lines:   []domain.ReservationLine{{GoodID: 1, WarehouseID: 1, Quantity: 1}},
+			sizes:   map[int]int{1: 61},
+			wantErr: ErrGoodDoesNotFitBox,
+		},
+		{
+			name:    "good without size",
+			lines:   []domain.ReservationLine{{GoodID: 1, WarehouseID: 1, Quantity: 1}},
+			sizes:   map[int]int{},
+			wantErr: ErrGoodNotFound,
+		},
+		{
+			name:  "line split across boxes",
+			lines: []domain.ReservationLine{{GoodID: 1, WarehouseID: 1, Quantity: 3}},
+			sizes: map[int]int{1: 25},
+			wantBoxes: []domain.PackedBox{
+				{Number: 1, BoxType: "L", WarehouseID: 1, Capacity: 60, Used: 50, Goods: []domain.GoodQuantity{{GoodID: 1, Quantity: 2}}},
+				{Number: 2, BoxType: "M", WarehouseID: 1, Capacity: 30, Used: 25, Goods: []domain.GoodQuantity{{GoodID: 1, Quantity: 1}}},
+			},
+			wantLines: []domain.PackingLine{
+				{GoodID: 1, WarehouseID: 1, Quantity: 3, Boxes: []domain.BoxAssignment{{Box: 1, Quantity: 2}, {Box: 2, Quantity: 1}}},
+			},
+		},
+		{
+			name: "larger goods go first and smaller fill the same box",
+			lines: []domain.ReservationLine{
+				{GoodID: 2, WarehouseID: 1, Quantity: 3},
+				{GoodID: 1, WarehouseID: 1, Quantity: 2},
+			},
+			sizes: map[int]int{1: 20, 2: 5},
+			wantBoxes: []domain.PackedBox{
+				{Number: 1, BoxType: "L", WarehouseID: 1, Capacity: 60, Used: 55, Goods: []domain.GoodQuantity{{GoodID: 1, Quantity: 2}, {GoodID: 2, Quantity: 3}}},
+			},
+			wantLines: []domain.PackingLine{
+				{GoodID: 1, WarehouseID: 1, Quantity: 2, Boxes: []domain.BoxAssignment{{Box: 1, Quantity: 2}}},
+				{GoodID: 2, WarehouseID: 1, Quantity: 3, Boxes: []domain.BoxAssignment{{Box: 1, Quantity: 3}}},
+			},
+		},
+		{
+			name: "warehouses are packed into separate boxes",
+			lines: []domain.ReservationLine{
+				{GoodID: 1, WarehouseID: 2, Quantity: 1},
+				{GoodID: 1, WarehouseID: 1, Quantity: 1},
+			},
+			sizes: map[int]int{1: 5},
+			wantBoxes: []domain.PackedBox{
+				{Number: 1, BoxType: "S", WarehouseID: 1, Capacity: 10, Used: 5, Goods: []domain.GoodQuantity{{GoodID: 1, Quantity: 1}}},
+				{Number: 2, BoxType: "S", WarehouseID: 2, Capacity: 10, Used: 5, Goods: []domain.GoodQuantity{{GoodID: 1, Quantity: 1}}},
+			},
+			wantLines: []domain.PackingLine{
+				{GoodID: 1, WarehouseID: 1, Quantity: 1, Boxes: []domain.BoxAssignment{{Box: 1, Quantity: 1}}},
+				{GoodID: 1, WarehouseID: 2, Quantity: 1, Boxes: []domain.BoxAssignment{{Box: 2, Quantity: 1}}},
+			},
+		},
+		{
+			name: "lines of one good in one warehouse are packed as one line",
+			lines: []domain.ReservationLine{
+				{GoodID: 1, WarehouseID: 1, Quantity: 2},
+				{GoodID: 2, WarehouseID: 1, Quantity: 1},
+				{GoodID: 1, WarehouseID: 1, Quantity: 1},
+			},
+			sizes: map[int]int{1: 5, 2: 5},
+			wantBoxes: []domain.PackedBox{
+				{Number: 1, BoxType: "M", WarehouseID: 1, Capacity: 30, Used: 20, Goods: []domain.GoodQuantity{{GoodID: 1, Quantity: 3}, {GoodID: 2, Quantity: 1}}},
+			},
+			wantLines: []domain.PackingLine{
+				{GoodID: 1, WarehouseID: 1, Quantity: 3, Boxes: []domain.BoxAssignment{{Box: 1, Quantity: 3}}},
+				{GoodID: 2, WarehouseID: 1, Quantity: 1, Boxes: []domain.BoxAssignment{{Box: 1, Quantity: 1}}},
+			},
+		},
+		{
+			name:  "box is downsized to the smallest type that fits",
+			lines: []domain.ReservationLine{{GoodID: 1, WarehouseID: 1, Quantity: 3}},
+			sizes: map[int]int{1: 10},
+			wantBoxes: []domain.PackedBox{
+				{Number: 1, BoxType: "M", WarehouseID: 1, Capacity: 30, Used: 30, Goods: []domain.GoodQuantity{{GoodID: 1, Quantity: 3}}},
+			},
+			wantLines: []domain.PackingLine{
+				{GoodID: 1, WarehouseID: 1, Quantity: 3, Boxes: []domain.BoxAssignment{{Box: 1, Quantity: 3}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			boxes, lines, err := packLines(tt.lines, tt.sizes, sortBoxTypes(DefaultBoxTypes))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if !reflect.DeepEqual(boxes, tt.wantBoxes) {
+				t.Errorf("boxes = %v, want %v", boxes, tt.wantBoxes)
+			}
+			if !reflect.DeepEqual(lines, tt.wantLines) {
+				t.Errorf("lines = %v, want %v", lines, tt.wantLines)
+			}
+		})
+	}
+}
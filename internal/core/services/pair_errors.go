@@ -0,0 +1,58 @@
+package services
+
+import (
+	"errors"
+	"warehouse/internal/adapters/repository"
+)
+
+// коды ошибок по отдельным парам в ответах на резервацию и снятие резерва
+const (
+	ErrorCodeInvalidGoodID        = "invalid_good_id"
+	ErrorCodeInvalidWarehouseID   = "invalid_warehouse_id"
+	ErrorCodeInvalidQuantity      = "invalid_quantity"
+	ErrorCodeGoodNotInWarehouse   = "good_not_in_warehouse"
+	ErrorCodeWarehouseUnavailable = "warehouse_unavailable"
+	ErrorCodeNotEnoughGoods       = "not_enough_goods"
+	ErrorCodeNotEnoughReserved    = "not_enough_reserved"
+	ErrorCodeSerialNotAvailable   = "serial_not_available"
+	ErrorCodeInvalidSerials       = "invalid_serials"
+	ErrorCodeInvalidReservationID = "invalid_reservation_id"
+	ErrorCodeReservationNotFound  = "reservation_not_found"
+	ErrorCodeReservationInactive  = "reservation_not_active"
+	ErrorCodeReservationAmbiguous = "reservation_ambiguous"
+	ErrorCodeReservationHasOrder  = "reservation_has_order"
+	ErrorCodeInternal             = "internal"
+)
+
+var pairErrorCodes = []struct {
+	repoErr error
+	err     error
+	code    string
+}{
+	{nil, ErrGoodIDisNegative, ErrorCodeInvalidGoodID},
+	{nil, ErrWarehouseIDisNegative, ErrorCodeInvalidWarehouseID},
+	{nil, ErrQuantityIsNegative, ErrorCodeInvalidQuantity},
+	{nil, ErrSerialIsEmpty, ErrorCodeInvalidSerials},
+	{nil, ErrSerialIsDuplicated, ErrorCodeInvalidSerials},
+	{nil, ErrSerialCountMismatch, ErrorCodeInvalidSerials},
+	{nil, ErrReservationIDisNegative, ErrorCodeInvalidReservationID},
+	{repository.ErrNotFound, ErrReservationNotFound, ErrorCodeReservationNotFound},
+	{repository.ErrReservationIsNotActive, ErrReservationIsNotActive, ErrorCodeReservationInactive},
+	{repository.ErrReservationIsAmbiguous, ErrReservationIsAmbiguous, ErrorCodeReservationAmbiguous},
+	{repository.ErrReservationHasOrder, ErrReservationHasOrder, ErrorCodeReservationHasOrder},
+	{repository.ErrFailedCheckGoodInWarehouse, ErrGoodWarehouseIsNotExist, ErrorCodeGoodNotInWarehouse},
+	{repository.ErrWarehouseIsUnavailable, ErrWarehouseIsUnavailable, ErrorCodeWarehouseUnavailable},
+	{repository.ErrNotEnoughGoods, ErrNotEnoughGoods, ErrorCodeNotEnoughGoods},
+	{repository.ErrNotEnoughReserved, ErrNotEnoughReserved, ErrorCodeNotEnoughReserved},
+	{repository.ErrSerialIsNotAvailable, ErrSerialIsNotAvailable, ErrorCodeSerialNotAvailable},
+}
+
+// pairError переводит ошибку пары в ошибку сервиса и подбирает для нее код
+func pairError(err error) (error, string) {
+	for _, e := range pairErrorCodes {
+		if errors.Is(err, e.err) || (e.repoErr != nil && errors.Is(err, e.repoErr)) {
+			return e.err, e.code
+		}
+	}
+	return err, ErrorCodeInternal
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"warehouse/internal/adapters/repository"
+	"warehouse/internal/core/domain"
+)
+
+// mergeGoodQuantities проверяет строки и складывает повторы одного товара и партии, серийные номера объединяются
+func (gs *GoodService) mergeGoodQuantities(lines []domain.GoodQuantity) ([]domain.GoodQuantity, error) {
+	if len(lines) == 0 {
+		return nil, ErrReceiptLinesIsEmpty
+	}
+
+	type key struct {
+		goodID int
+		lot    domain.LotRef
+	}
+	byKey := make(map[key]int, len(lines))
+	lots := make(map[key]*domain.LotRef, len(lines))
+	serials := make(map[key][]string, len(lines))
+	seen := make(map[string]struct{})
+	for _, l := range lines {
+		if !gs.validateID(l.GoodID) {
+			return nil, ErrGoodIDisNegative
+		}
+		if !gs.validateID(l.Quantity) {
+			return nil, ErrQuantityIsNegative
+		}
+		if err := validateLot(l.Lot); err != nil {
+			return nil, err
+		}
+		if err := validateSerials(l.Serials, l.Quantity); err != nil {
+			return nil, err
+		}
+		for _, s := range l.Serials {
+			if _, ok := seen[s]; ok {
+				return nil, ErrSerialIsDuplicated
+			}
+			seen[s] = struct{}{}
+		}
+		k := key{goodID: l.GoodID}
+		if l.Lot != nil {
+			k.lot = *l.Lot
+		}
+		byKey[k] += l.Quantity
+		lots[k] = l.Lot
+		serials[k] = append(serials[k], l.Serials...)
+	}
+
+	merged := make([]domain.GoodQuantity, 0, len(byKey))
+	for k, quantity := range byKey {
+		if len(serials[k]) > 0 && len(serials[k]) != quantity {
+			// одна из объединенных строк пришла без номеров
+			return nil, ErrSerialCountMismatch
+		}
+		merged = append(merged, domain.GoodQuantity{GoodID: k.goodID, Quantity: quantity, Lot: lots[k], Serials: serials[k]})
+	}
+	slices.SortFunc(merged, func(a, b domain.GoodQuantity) int {
+		if a.GoodID != b.GoodID {
+			return a.GoodID - b.GoodID
+		}
+		return cmp.Compare(lotNumber(a.Lot), lotNumber(b.Lot))
+	})
+	return merged, nil
+}
+
+func receiptError(err error) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return ErrReceiptNotFound
+	}
+	if errors.Is(err, repository.ErrReceiptIsClosed) {
+		return ErrReceiptIsClosed
+	}
+	if errors.Is(err, repository.ErrIsNotExist) {
+		return ErrGoodWarehouseIsNotExist
+	}
+	if errors.Is(err, repository.ErrWarehouseIsUnavailable) {
+		return ErrWarehouseIsUnavailable
+	}
+	if errors.Is(err, repository.ErrLotExpiryMismatch) {
+		return ErrLotExpiryMismatch
+	}
+	if errors.Is(err, repository.ErrCapacityExceeded) {
+		return ErrCapacityExceeded
+	}
+	if errors.Is(err, repository.ErrGoodIsKit) {
+		return ErrGoodIsKit
+	}
+	return serialError(err)
+}
+
+// CreateReceipt регистрирует ожидаемую поставку, остаток при этом не меняется
+func (gs *GoodService) CreateReceipt(ctx context.Context, warehouseID int, supplierRef string, lines []domain.GoodQuantity) (domain.Receipt, error) {
+	if !gs.validateID(warehouseID) {
+		return domain.Receipt{}, ErrWarehouseIDisNegative
+	}
+	if supplierRef == "" {
+		return domain.Receipt{}, ErrSupplierRefIsEmpty
+	}
+	lines, err := gs.mergeGoodQuantities(lines)
+	if err != nil {
+		return domain.Receipt{}, err
+	}
+
+	rc, err := gs.repo.CreateReceipt(ctx, warehouseID, supplierRef, lines)
+	if err != nil {
+		if e := receiptError(err); e != nil {
+			return domain.Receipt{}, e
+		}
+		return domain.Receipt{}, fmt.Errorf("error create receipt: %w", err)
+	}
+	return rc, nil
+}
+
+func (gs *GoodService) GetReceipt(ctx context.Context, id int) (domain.Receipt, error) {
+	if !gs.validateID(id) {
+		return domain.Receipt{}, ErrReceiptIDisNegative
+	}
+
+	rc, err := gs.repo.GetReceipt(ctx, id)
+	if err != nil {
+		if e := receiptError(err); e != nil {
+			return domain.Receipt{}, e
+		}
+		return domain.Receipt{}, fmt.Errorf("error get receipt: %w", err)
+	}
+	return rc, nil
+}
+
+// ReceiveReceipt принимает фактически пришедшее количество, можно вызывать несколько раз до закрытия
+func (gs *GoodService) ReceiveReceipt(ctx context.Context, id int, lines []domain.GoodQuantity) (domain.Receipt, error) {
+	if !gs.validateID(id) {
+		return domain.Receipt{}, ErrReceiptIDisNegative
+	}
+	lines, err := gs.mergeGoodQuantities(lines)
+	if err != nil {
+		return domain.Receipt{}, err
+	}
+
+	rc, err := gs.repo.ReceiveReceipt(ctx, id, lines)
+	if err != nil {
+		if e := receiptError(err); e != nil {
+			return domain.Receipt{}, e
+		}
+		return domain.Receipt{}, fmt.Errorf("error receive receipt: %w", err)
+	}
+
+	keys := make([]domain.StockKey, 0, len(lines))
+	for _, l := range lines {
+		keys = append(keys, domain.StockKey{GoodID: l.GoodID, WarehouseID: rc.WarehouseID})
+	}
+	gs.checkStockThresholds(ctx, keys)
+	return rc, nil
+}
+
+func (gs *GoodService) CloseReceipt(ctx context.Context, id int) (domain.Receipt, error) {
+	if !gs.validateID(id) {
+		return domain.Receipt{}, ErrReceiptIDisNegative
+	}
+
+	rc, err := gs.repo.CloseReceipt(ctx, id)
+	if err != nil {
+		if e := receiptError(err); e != nil {
+			return domain.Receipt{}, e
+		}
+		return domain.Receipt{}, fmt.Errorf("error close receipt: %w", err)
+	}
+	return rc, nil
+}
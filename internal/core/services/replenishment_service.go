@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+	"warehouse/internal/core/domain"
+)
+
+var DefaultReplenishmentParams = domain.ReplenishmentParams{
+	WindowDays:      30,
+	LeadTimeDays:    7,
+	SafetyStockDays: 3,
+}
+
+// GetReplenishment предлагает объем дозаказа по каждой строке goods_warehouse.
+// Средний дневной спрос считается по отгрузкам за окно, точка заказа - спрос за срок поставки
+// плюс страховой запас. Предлагается довезти до точки заказа с учетом товара в пути
+func (gs *GoodService) GetReplenishment(ctx context.Context, req domain.ReplenishmentRequest) ([]domain.ReplenishmentSuggestion, error) {
+	if req.GoodID < 0 {
+		return nil, ErrGoodIDisNegative
+	}
+	if req.WarehouseID < 0 {
+		return nil, ErrWarehouseIDisNegative
+	}
+	if req.Params.WindowDays == 0 {
+		req.Params.WindowDays = gs.replenishment.WindowDays
+	}
+	if req.Params.LeadTimeDays == 0 {
+		req.Params.LeadTimeDays = gs.replenishment.LeadTimeDays
+	}
+	if req.Params.SafetyStockDays == 0 {
+		req.Params.SafetyStockDays = gs.replenishment.SafetyStockDays
+	}
+	if req.Params.WindowDays < 0 || req.Params.LeadTimeDays < 0 || req.Params.SafetyStockDays < 0 {
+		return nil, ErrInvalidReplenishmentParams
+	}
+
+	since := time.Now().AddDate(0, 0, -req.Params.WindowDays)
+	demand, err := gs.repo.GetStockDemand(ctx, req.GoodID, req.WarehouseID, since)
+	if err != nil {
+		return nil, fmt.Errorf("error get replenishment: %w", err)
+	}
+
+	res := make([]domain.ReplenishmentSuggestion, 0, len(demand))
+	for _, d := range demand {
+		avg := float64(d.Outbound) / float64(req.Params.WindowDays)
+		s := domain.ReplenishmentSuggestion{
+			GoodID:         d.GoodID,
+			WarehouseID:    d.WarehouseID,
+			Free:           d.Free,
+			InTransit:      d.InTransit,
+			AvgDailyDemand: math.Round(avg*100) / 100,
+			SafetyStock:    int(math.Ceil(avg * float64(req.Params.SafetyStockDays))),
+		}
+		s.ReorderPoint = int(math.Ceil(avg*float64(req.Params.LeadTimeDays))) + s.SafetyStock
+		s.SuggestedQuantity = max(0, s.ReorderPoint-s.Free-s.InTransit)
+		res = append(res, s)
+	}
+	return res, nil
+}
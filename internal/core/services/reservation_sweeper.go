@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+	"warehouse/internal/core/domain"
+)
+
+const sweeperActor = "reservation-sweeper"
+
+const DefaultSweepInterval = time.Minute
+
+// ReservationSweeper периодически снимает просроченные резервации
+// и удаляет устаревшие ключи идемпотентности
+type ReservationSweeper struct {
+	svc      *GoodService
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewReservationSweeper(svc *GoodService, interval time.Duration) *ReservationSweeper {
+	if interval <= 0 {
+		interval = DefaultSweepInterval
+	}
+	return &ReservationSweeper{
+		svc:      svc,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Run блокируется до отмены ctx или вызова Stop
+func (s *ReservationSweeper) Run(ctx context.Context) error {
+	defer close(s.done)
+	ctx = domain.ContextWithActor(ctx, sweeperActor)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.stop:
+			return nil
+		case <-ticker.C:
+			cnt, err := s.svc.ExpireReservations(ctx)
+			if err != nil {
+				//ошибку только логируем, иначе одна неудачная попытка положит весь сервер
+				log.Printf("reservation sweeper: %v", err)
+			}
+			if cnt > 0 {
+				log.Printf("reservation sweeper: expired %d reservations", cnt)
+			}
+			if _, err = s.svc.DeleteExpiredIdempotencyKeys(ctx); err != nil {
+				log.Printf("reservation sweeper: %v", err)
+			}
+		}
+	}
+}
+
+// Stop останавливает Run и дожидается его завершения
+func (s *ReservationSweeper) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+}
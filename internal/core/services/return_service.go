@@ -0,0 +1,127 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"warehouse/internal/adapters/repository"
+	"warehouse/internal/core/domain"
+)
+
+func (gs *GoodService) validateReturnLines(lines []domain.ReturnLine) ([]domain.ReturnLine, error) {
+	if len(lines) == 0 {
+		return nil, ErrReturnLinesIsEmpty
+	}
+
+	type key struct {
+		goodID      int
+		disposition domain.ReturnDisposition
+	}
+	merged := make(map[key]domain.ReturnLine, len(lines))
+	for _, l := range lines {
+		if !gs.validateID(l.GoodID) {
+			return nil, ErrGoodIDisNegative
+		}
+		if !gs.validateID(l.Quantity) {
+			return nil, ErrQuantityIsNegative
+		}
+		switch l.Disposition {
+		case domain.DispositionRestock, domain.DispositionQuarantine, domain.DispositionScrap:
+		default:
+			return nil, ErrUnknownDisposition
+		}
+		m := merged[key{l.GoodID, l.Disposition}]
+		m.GoodID, m.Disposition = l.GoodID, l.Disposition
+		m.Quantity += l.Quantity
+		m.Serials = append(m.Serials, l.Serials...)
+		merged[key{l.GoodID, l.Disposition}] = m
+	}
+
+	res := make([]domain.ReturnLine, 0, len(merged))
+	for _, l := range merged {
+		if err := validateSerials(l.Serials, l.Quantity); err != nil {
+			return nil, err
+		}
+		res = append(res, l)
+	}
+	slices.SortFunc(res, func(a, b domain.ReturnLine) int {
+		if a.GoodID != b.GoodID {
+			return a.GoodID - b.GoodID
+		}
+		return cmp.Compare(a.Disposition, b.Disposition)
+	})
+	return res, nil
+}
+
+// CreateReturn принимает возврат по отгрузке или по отгруженному заказу
+func (gs *GoodService) CreateReturn(ctx context.Context, req domain.ReturnRequest) (domain.Return, error) {
+	if (req.ShipmentID == 0) == (req.OrderID == 0) {
+		return domain.Return{}, ErrReturnSourceIsInvalid
+	}
+	if req.ShipmentID < 0 {
+		return domain.Return{}, ErrShipmentIDisNegative
+	}
+	if req.OrderID < 0 {
+		return domain.Return{}, ErrOrderIDisNegative
+	}
+	if !gs.validateID(req.WarehouseID) {
+		return domain.Return{}, ErrWarehouseIDisNegative
+	}
+	lines, err := gs.validateReturnLines(req.Lines)
+	if err != nil {
+		return domain.Return{}, err
+	}
+	req.Lines = lines
+
+	if req.OrderID > 0 {
+		o, err := gs.GetOrder(ctx, req.OrderID)
+		if err != nil {
+			return domain.Return{}, err
+		}
+		if o.Status != domain.OrderShipped || o.ShipmentID == nil {
+			return domain.Return{}, ErrOrderIsNotShipped
+		}
+		req.ShipmentID = *o.ShipmentID
+	}
+
+	rt, err := gs.repo.CreateReturn(ctx, req)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.Return{}, ErrShipmentNotFound
+		}
+		if errors.Is(err, repository.ErrIsNotExist) {
+			return domain.Return{}, ErrWarehouseIsNotExist
+		}
+		if errors.Is(err, repository.ErrWarehouseIsUnavailable) {
+			return domain.Return{}, ErrWarehouseIsUnavailable
+		}
+		if errors.Is(err, repository.ErrReturnExceedsShipped) {
+			return domain.Return{}, ErrReturnExceedsShipped
+		}
+		if errors.Is(err, repository.ErrLotExpiryMismatch) {
+			return domain.Return{}, ErrLotExpiryMismatch
+		}
+		if e := serialError(err); e != nil {
+			return domain.Return{}, e
+		}
+		return domain.Return{}, fmt.Errorf("error create return: %w", err)
+	}
+	return rt, nil
+}
+
+func (gs *GoodService) GetReturn(ctx context.Context, id int) (domain.Return, error) {
+	if !gs.validateID(id) {
+		return domain.Return{}, ErrReturnIDisNegative
+	}
+
+	rt, err := gs.repo.GetReturn(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.Return{}, ErrReturnNotFound
+		}
+		return domain.Return{}, fmt.Errorf("error get return: %w", err)
+	}
+	return rt, nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"warehouse/internal/adapters/repository"
+	"warehouse/internal/core/domain"
+)
+
+// validateSerials проверяет серийные номера строки. Пустой список допустим,
+// обязательность номеров для товара проверяет репозиторий
+func validateSerials(serials []string, quantity int) error {
+	if len(serials) == 0 {
+		return nil
+	}
+	if len(serials) != quantity {
+		return ErrSerialCountMismatch
+	}
+	seen := make(map[string]struct{}, len(serials))
+	for _, s := range serials {
+		if s == "" {
+			return ErrSerialIsEmpty
+		}
+		if _, ok := seen[s]; ok {
+			return ErrSerialIsDuplicated
+		}
+		seen[s] = struct{}{}
+	}
+	return nil
+}
+
+// serialError переводит ошибки серийных номеров в ошибки сервиса, nil - если ошибка другая
+func serialError(err error) error {
+	if errors.Is(err, repository.ErrGoodIsNotSerialTracked) {
+		return ErrGoodIsNotSerialTracked
+	}
+	if errors.Is(err, repository.ErrSerialsIsRequired) {
+		return ErrSerialsIsRequired
+	}
+	if errors.Is(err, repository.ErrSerialIsExist) {
+		return fmt.Errorf("%w: %s", ErrSerialIsExist, err)
+	}
+	if errors.Is(err, repository.ErrSerialIsNotAvailable) {
+		return ErrSerialIsNotAvailable
+	}
+	if errors.Is(err, repository.ErrSerialCountMismatch) {
+		return ErrSerialCountMismatch
+	}
+	return nil
+}
+
+// GetSerial возвращает текущее положение серийного номера и историю его движений
+func (gs *GoodService) GetSerial(ctx context.Context, number string) (domain.Serial, error) {
+	if number == "" {
+		return domain.Serial{}, ErrSerialIsEmpty
+	}
+
+	s, err := gs.repo.GetSerial(ctx, number)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.Serial{}, ErrSerialNotFound
+		}
+		return domain.Serial{}, fmt.Errorf("error get serial: %w", err)
+	}
+	return s, nil
+}
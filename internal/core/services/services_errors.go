@@ -3,17 +3,104 @@ package services
 import "errors"
 
 var (
-	ErrGoodNotFound            = errors.New("good with this id is not found")
-	ErrGoodIDisNegative        = errors.New("good id is negative")
-	ErrWarehouseIDisNegative   = errors.New("warehouse id is negative")
-	ErrGoodIsExist             = errors.New("good with this id already exist")
-	ErrInvalidGood             = errors.New("good is invalid")
-	ErrGoodIsNotExist          = errors.New("good with this id is not exist")
-	ErrGoodWarehouseIsNotExist = errors.New("good or warehouse with this id is not exist")
-	ErrReservation             = errors.New("good in this warehouse is not exist")
-	ErrWarehouseNotFound       = errors.New("warehouse with this id is not found")
-	ErrInvalidWarehouse        = errors.New("warehouse is invalid")
-	ErrWarehouseIsExist        = errors.New("warehouse whit this id is exist")
-	ErrWarehouseIsNotExist     = errors.New("warehouse with this id is not exist")
-	ErrCountIsNegative         = errors.New("count is negative")
+	ErrGoodNotFound               = errors.New("good with this id is not found")
+	ErrGoodIDisNegative           = errors.New("good id is negative")
+	ErrWarehouseIDisNegative      = errors.New("warehouse id is negative")
+	ErrGoodIsExist                = errors.New("good with this id already exist")
+	ErrInvalidGood                = errors.New("good is invalid")
+	ErrGoodIsNotExist             = errors.New("good with this id is not exist")
+	ErrGoodWarehouseIsNotExist    = errors.New("good or warehouse with this id is not exist")
+	ErrReservation                = errors.New("good in this warehouse is not exist")
+	ErrWarehouseNotFound          = errors.New("warehouse with this id is not found")
+	ErrInvalidWarehouse           = errors.New("warehouse is invalid")
+	ErrWarehouseIsExist           = errors.New("warehouse whit this id is exist")
+	ErrWarehouseIsNotExist        = errors.New("warehouse with this id is not exist")
+	ErrCountIsNegative            = errors.New("count is negative")
+	ErrQuantityIsNegative         = errors.New("quantity is negative")
+	ErrReservationIDisNegative    = errors.New("reservation id is negative")
+	ErrReservationNotFound        = errors.New("reservation with this id is not found")
+	ErrReservationIsNotActive     = errors.New("reservation with this id is not active")
+	ErrReservationIsAmbiguous     = errors.New("several active reservations hold this good in this warehouse, reservation id is required")
+	ErrTTLIsNegative              = errors.New("ttl is negative")
+	ErrNotEnoughGoods             = errors.New("not enough free goods in this warehouse")
+	ErrWarehouseIsUnavailable     = errors.New("warehouse with this id is unavailable")
+	ErrUnknownStrategy            = errors.New("unknown allocation strategy")
+	ErrNotEnoughReserved          = errors.New("reserved quantity of good in this warehouse is less than requested")
+	ErrShipmentIDisNegative       = errors.New("shipment id is negative")
+	ErrShipmentNotFound           = errors.New("shipment with this id is not found")
+	ErrSameWarehouse              = errors.New("source and destination warehouses are the same")
+	ErrTransferIDisNegative       = errors.New("transfer id is negative")
+	ErrTransferNotFound           = errors.New("transfer with this id is not found")
+	ErrTransferIsNotInTransit     = errors.New("transfer with this id is not in transit")
+	ErrDeltaIsZero                = errors.New("delta is zero")
+	ErrUnknownReason              = errors.New("unknown reason code")
+	ErrBelowReserved              = errors.New("count of good in this warehouse can not be less than reserved")
+	ErrCountSessionIDisNegative   = errors.New("count session id is negative")
+	ErrCountSessionNotFound       = errors.New("count session with this id is not found")
+	ErrCountSessionIsNotOpen      = errors.New("count session with this id is not open")
+	ErrCountSessionIsExist        = errors.New("open count session for this warehouse already exist")
+	ErrCountedGoodsIsEmpty        = errors.New("counted goods are empty")
+	ErrInvalidThreshold           = errors.New("reorder point is negative or greater than target level")
+	ErrSupplierRefIsEmpty         = errors.New("supplier reference is empty")
+	ErrReceiptLinesIsEmpty        = errors.New("receipt lines are empty")
+	ErrReceiptIDisNegative        = errors.New("receipt id is negative")
+	ErrReceiptNotFound            = errors.New("receipt with this id is not found")
+	ErrReceiptIsClosed            = errors.New("receipt with this id is closed")
+	ErrOrderIDisNegative          = errors.New("order id is negative")
+	ErrOrderNotFound              = errors.New("order with this id is not found")
+	ErrOrderIsExist               = errors.New("order for this reservation already exist")
+	ErrOrderIsNotPicking          = errors.New("order with this id is not picking")
+	ErrReservationHasOrder        = errors.New("reservation is being picked by an order, release or ship it through the order")
+	ErrPickListIDisNegative       = errors.New("pick list id is negative")
+	ErrPickListNotFound           = errors.New("pick list with this id is not found")
+	ErrGoodIsNotInPickList        = errors.New("good is not in this pick list")
+	ErrPickExceedsQuantity        = errors.New("picked quantity exceeds quantity in pick list")
+	ErrPickedGoodsIsEmpty         = errors.New("picked goods are empty")
+	ErrReturnSourceIsInvalid      = errors.New("exactly one of shipment id and order id must be set")
+	ErrOrderIsNotShipped          = errors.New("order with this id is not shipped")
+	ErrReturnLinesIsEmpty         = errors.New("return lines are empty")
+	ErrUnknownDisposition         = errors.New("unknown disposition")
+	ErrReturnExceedsShipped       = errors.New("returned quantity exceeds shipped quantity")
+	ErrReturnIDisNegative         = errors.New("return id is negative")
+	ErrReturnNotFound             = errors.New("return with this id is not found")
+	ErrUnknownStockStatus         = errors.New("unknown stock status")
+	ErrSameStockStatus            = errors.New("source and destination stock statuses are the same")
+	ErrLotNumberIsEmpty           = errors.New("lot number is empty")
+	ErrLotExpiryIsEmpty           = errors.New("lot expiry date is empty")
+	ErrLotExpiryMismatch          = errors.New("lot with this number already exist with another expiry date")
+	ErrDaysIsNegative             = errors.New("days is negative")
+	ErrSerialIsEmpty              = errors.New("serial number is empty")
+	ErrSerialIsDuplicated         = errors.New("serial number is duplicated in request")
+	ErrSerialCountMismatch        = errors.New("number of serials does not match quantity")
+	ErrSerialsIsRequired          = errors.New("serials are required for serial tracked good")
+	ErrGoodIsNotSerialTracked     = errors.New("good is not serial tracked")
+	ErrSerialIsExist              = errors.New("serial is already in stock")
+	ErrSerialIsNotAvailable       = errors.New("serial is not in stock in this warehouse")
+	ErrSerialNotFound             = errors.New("serial is not found")
+	ErrLocationCodeIsEmpty        = errors.New("location code is empty")
+	ErrLocationIsExist            = errors.New("location with this code already exist")
+	ErrZoneIDisNegative           = errors.New("zone id is negative")
+	ErrZoneNotFound               = errors.New("zone with this id is not found")
+	ErrAisleIDisNegative          = errors.New("aisle id is negative")
+	ErrAisleNotFound              = errors.New("aisle with this id is not found")
+	ErrBinIDisNegative            = errors.New("bin id is negative")
+	ErrBinNotFound                = errors.New("bin with this id is not found")
+	ErrSameBin                    = errors.New("source and destination bins are the same")
+	ErrBinsInDifferentWarehouses  = errors.New("bins are in different warehouses")
+	ErrNotEnoughUnbinned          = errors.New("not enough goods waiting for put-away in this warehouse")
+	ErrNotEnoughInBin             = errors.New("not enough goods in this bin")
+	ErrBinIsNotInWarehouse        = errors.New("bin is not in this warehouse")
+	ErrCapacityExceeded           = errors.New("warehouse capacity is exceeded")
+	ErrPackingSourceIsInvalid     = errors.New("exactly one of reservation id and order id must be set")
+	ErrGoodDoesNotFitBox          = errors.New("good does not fit into any box type")
+	ErrGoodIsKit                  = errors.New("good is a kit, stock is kept for its components")
+	ErrKitComponentsIsDuplicated  = errors.New("kit component is duplicated in request")
+	ErrKitContainsItself          = errors.New("kit can not contain itself")
+	ErrKitHasStock                = errors.New("good has its own stock and can not become a kit")
+	ErrNestedKit                  = errors.New("kit can not contain other kits or be a component of a kit")
+	ErrInvalidReplenishmentParams = errors.New("replenishment window, lead time or safety stock is negative")
+	ErrInvalidTimeRange           = errors.New("time range is invalid")
+	ErrInvalidLimit               = errors.New("limit is invalid")
+	ErrIdempotencyKeyInProgress   = errors.New("request with this idempotency key is still in progress")
+	ErrIdempotencyKeyMismatch     = errors.New("idempotency key is already used with another request")
 )
@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"warehouse/internal/adapters/repository"
+	"warehouse/internal/core/domain"
+)
+
+func validStockStatus(status domain.StockStatus) bool {
+	switch status {
+	case domain.StockSellable, domain.StockQuarantined, domain.StockDamaged:
+		return true
+	}
+	return false
+}
+
+// MoveStockStatus переводит единицы между продаваемым, карантинным и поврежденным остатком.
+// Из продаваемого можно увести только незарезервированные единицы
+func (gs *GoodService) MoveStockStatus(ctx context.Context, req domain.StatusMoveRequest) (domain.StockLevel, error) {
+	if !gs.validateID(req.GoodID) {
+		return domain.StockLevel{}, ErrGoodIDisNegative
+	}
+	if !gs.validateID(req.WarehouseID) {
+		return domain.StockLevel{}, ErrWarehouseIDisNegative
+	}
+	if !validStockStatus(req.From) || !validStockStatus(req.To) {
+		return domain.StockLevel{}, ErrUnknownStockStatus
+	}
+	if req.From == req.To {
+		return domain.StockLevel{}, ErrSameStockStatus
+	}
+	if !gs.validateID(req.Quantity) {
+		return domain.StockLevel{}, ErrQuantityIsNegative
+	}
+	if err := validateSerials(req.Serials, req.Quantity); err != nil {
+		return domain.StockLevel{}, err
+	}
+
+	level, err := gs.repo.MoveStockStatus(ctx, req)
+	if err != nil {
+		if errors.Is(err, repository.ErrFailedCheckGoodInWarehouse) {
+			return domain.StockLevel{}, ErrGoodWarehouseIsNotExist
+		}
+		if errors.Is(err, repository.ErrNotEnoughGoods) {
+			return domain.StockLevel{}, ErrNotEnoughGoods
+		}
+		if e := serialError(err); e != nil {
+			return domain.StockLevel{}, e
+		}
+		return domain.StockLevel{}, fmt.Errorf("error move stock status: %w", err)
+	}
+
+	if req.From == domain.StockSellable {
+		gs.checkStockThresholds(ctx, []domain.StockKey{{GoodID: req.GoodID, WarehouseID: req.WarehouseID}})
+	}
+	return level, nil
+}
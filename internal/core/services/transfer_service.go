@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"warehouse/internal/adapters/repository"
+	"warehouse/internal/core/domain"
+)
+
+// Transfer переносит свободные (незарезервированные) единицы товара между складами
+func (gs *GoodService) Transfer(ctx context.Context, req domain.TransferRequest) (domain.Transfer, error) {
+	if !gs.validateID(req.GoodID) {
+		return domain.Transfer{}, ErrGoodIDisNegative
+	}
+	if !gs.validateID(req.FromWarehouseID) || !gs.validateID(req.ToWarehouseID) {
+		return domain.Transfer{}, ErrWarehouseIDisNegative
+	}
+	if req.FromWarehouseID == req.ToWarehouseID {
+		return domain.Transfer{}, ErrSameWarehouse
+	}
+	if !gs.validateID(req.Quantity) {
+		return domain.Transfer{}, ErrQuantityIsNegative
+	}
+	if req.FromBinID != 0 && !gs.validateID(req.FromBinID) {
+		return domain.Transfer{}, ErrBinIDisNegative
+	}
+	if err := validateSerials(req.Serials, req.Quantity); err != nil {
+		return domain.Transfer{}, err
+	}
+
+	t, err := gs.repo.Transfer(ctx, req)
+	if err != nil {
+		if errors.Is(err, repository.ErrIsNotExist) || errors.Is(err, repository.ErrFailedCheckGoodInWarehouse) {
+			return domain.Transfer{}, ErrGoodWarehouseIsNotExist
+		}
+		if errors.Is(err, repository.ErrWarehouseIsUnavailable) {
+			return domain.Transfer{}, ErrWarehouseIsUnavailable
+		}
+		if errors.Is(err, repository.ErrCapacityExceeded) {
+			return domain.Transfer{}, ErrCapacityExceeded
+		}
+		if errors.Is(err, repository.ErrLotExpiryMismatch) {
+			return domain.Transfer{}, ErrLotExpiryMismatch
+		}
+		if errors.Is(err, repository.ErrNotEnoughGoods) {
+			return domain.Transfer{}, ErrNotEnoughGoods
+		}
+		if e := serialError(err); e != nil {
+			return domain.Transfer{}, e
+		}
+		if e := stockBinError(err); e != nil {
+			return domain.Transfer{}, e
+		}
+		return domain.Transfer{}, fmt.Errorf("error transfer: %w", err)
+	}
+
+	gs.checkStockThresholds(ctx, []domain.StockKey{{GoodID: t.GoodID, WarehouseID: t.FromWarehouseID}})
+	return t, nil
+}
+
+func (gs *GoodService) ReceiveTransfer(ctx context.Context, id int) (domain.Transfer, error) {
+	if !gs.validateID(id) {
+		return domain.Transfer{}, ErrTransferIDisNegative
+	}
+
+	t, err := gs.repo.ReceiveTransfer(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.Transfer{}, ErrTransferNotFound
+		}
+		if errors.Is(err, repository.ErrTransferIsNotInTransit) {
+			return domain.Transfer{}, ErrTransferIsNotInTransit
+		}
+		if errors.Is(err, repository.ErrWarehouseIsUnavailable) {
+			return domain.Transfer{}, ErrWarehouseIsUnavailable
+		}
+		if errors.Is(err, repository.ErrCapacityExceeded) {
+			return domain.Transfer{}, ErrCapacityExceeded
+		}
+		if errors.Is(err, repository.ErrLotExpiryMismatch) {
+			return domain.Transfer{}, ErrLotExpiryMismatch
+		}
+		return domain.Transfer{}, fmt.Errorf("error receive transfer: %w", err)
+	}
+	return t, nil
+}
+
+func (gs *GoodService) GetTransfer(ctx context.Context, id int) (domain.Transfer, error) {
+	if !gs.validateID(id) {
+		return domain.Transfer{}, ErrTransferIDisNegative
+	}
+
+	t, err := gs.repo.GetTransfer(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.Transfer{}, ErrTransferNotFound
+		}
+		return domain.Transfer{}, fmt.Errorf("error get transfer: %w", err)
+	}
+	return t, nil
+}
@@ -24,7 +24,7 @@ func (ws *WarehouseService) validateID(ID int) bool {
 }
 
 func (ws *WarehouseService) validateWarehouse(warehouse domain.Warehouse) bool {
-	return warehouse.ID > 0 && warehouse.Name != ""
+	return warehouse.ID > 0 && warehouse.Name != "" && warehouse.Capacity >= 0
 }
 
 func (ws *WarehouseService) GetWarehouse(ctx context.Context, warehouseID int) (domain.Warehouse, error) {
@@ -101,3 +101,34 @@ func (ws *WarehouseService) GetCountGoodsByWarehouseID(ctx context.Context, ware
 	}
 	return cnt, nil
 }
+
+func (ws *WarehouseService) SetAvailability(ctx context.Context, warehouseID int, isAvailable bool) error {
+	if !ws.validateID(warehouseID) {
+		return ErrWarehouseIDisNegative
+	}
+
+	if err := ws.repo.SetWarehouseAvailability(ctx, warehouseID, isAvailable); err != nil {
+		if errors.Is(err, repository.ErrIsNotExist) {
+			return ErrWarehouseIsNotExist
+		}
+		return fmt.Errorf("error set warehouse availability: %w", err)
+	}
+
+	return nil
+}
+
+// GetWarehouseCapacity возвращает занятый и свободный объем склада, при warehouseID = 0 - всех складов
+func (ws *WarehouseService) GetWarehouseCapacity(ctx context.Context, warehouseID int) ([]domain.WarehouseCapacity, error) {
+	if warehouseID < 0 {
+		return nil, ErrWarehouseIDisNegative
+	}
+
+	report, err := ws.repo.GetWarehouseCapacity(ctx, warehouseID)
+	if err != nil {
+		if errors.Is(err, repository.ErrIsNotExist) {
+			return nil, ErrWarehouseIsNotExist
+		}
+		return nil, fmt.Errorf("error get warehouse capacity: %w", err)
+	}
+	return report, nil
+}